@@ -2,9 +2,11 @@ package boltprojection
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/dogmatiq/dogma"
 	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/internal/logging"
 	"github.com/dogmatiq/projectionkit/internal/unboundhandler"
 	"github.com/dogmatiq/projectionkit/resource"
 	"go.etcd.io/bbolt"
@@ -15,7 +17,9 @@ import (
 type adaptor struct {
 	db      *bbolt.DB
 	handler MessageHandler
+	key     string
 	repo    *ResourceRepository
+	logger  *slog.Logger
 }
 
 // New returns a new Dogma projection message handler by binding a
@@ -26,19 +30,33 @@ type adaptor struct {
 func New(
 	db *bbolt.DB,
 	h MessageHandler,
+	options ...Option,
 ) dogma.ProjectionMessageHandler {
 	if db == nil {
 		return unboundhandler.New(h)
 	}
 
-	return &adaptor{
+	key := identity.Key(h)
+
+	a := &adaptor{
 		db:      db,
 		handler: h,
+		key:     key,
 		repo: NewResourceRepository(
 			db,
-			identity.Key(h),
+			key,
 		),
 	}
+
+	for _, opt := range options {
+		opt.applyToAdaptor(a)
+	}
+
+	if h, ok := h.(ResettableMessageHandler); ok {
+		a.repo.reset = h.Reset
+	}
+
+	return a
 }
 
 // Configure produces a configuration for this handler by calling methods on
@@ -54,13 +72,17 @@ func (a *adaptor) HandleEvent(
 	s dogma.ProjectionEventScope,
 	m dogma.Event,
 ) (bool, error) {
-	return a.repo.UpdateResourceVersionFn(
+	ok, err := a.repo.UpdateResourceVersionFn(
 		ctx,
 		r, c, n,
 		func(ctx context.Context, tx *bbolt.Tx) error {
 			return a.handler.HandleEvent(ctx, tx, s, m)
 		},
 	)
+
+	logging.HandleEvent(ctx, a.logger, a.key, r, c, n, ok, err)
+
+	return ok, err
 }
 
 // ResourceVersion returns the version of the resource r.
@@ -71,12 +93,16 @@ func (a *adaptor) ResourceVersion(ctx context.Context, r []byte) ([]byte, error)
 // CloseResource informs the projection that the resource r will not be
 // used in any future calls to HandleEvent().
 func (a *adaptor) CloseResource(ctx context.Context, r []byte) error {
-	return a.repo.DeleteResource(ctx, r)
+	err := a.repo.DeleteResource(ctx, r)
+	logging.CloseResource(ctx, a.logger, a.key, r, err)
+	return err
 }
 
 // Compact reduces the size of the projection's data.
 func (a *adaptor) Compact(ctx context.Context, s dogma.ProjectionCompactScope) error {
-	return a.handler.Compact(ctx, a.db, s)
+	err := a.handler.Compact(ctx, a.db, s)
+	logging.Compact(ctx, a.logger, a.key, err)
+	return err
 }
 
 // ResourceRepository returns a repository that can be used to manipulate the
@@ -1,17 +1,19 @@
 package boltprojection_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io/ioutil"
+	"log/slog"
 	"os"
 
 	"github.com/dogmatiq/dogma"
 	. "github.com/dogmatiq/enginekit/enginetest/stubs"
 	. "github.com/dogmatiq/projectionkit/boltprojection"
 	"github.com/dogmatiq/projectionkit/boltprojection/fixtures" // can't dot-import due to conflict
-	"github.com/dogmatiq/projectionkit/internal/adaptortest"
 	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/resource"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"go.etcd.io/bbolt"
@@ -56,8 +58,6 @@ var _ = Describe("type adaptor", func() {
 		}
 	})
 
-	adaptortest.DescribeAdaptor(&ctx, &adaptor)
-
 	Describe("func New()", func() {
 		It("returns an unbound handler if the database is nil", func() {
 			adaptor = New(nil, handler)
@@ -101,6 +101,67 @@ var _ = Describe("type adaptor", func() {
 		})
 	})
 
+	Describe("func ResetHandler()", func() {
+		It("calls the handler's Reset() method", func() {
+			called := false
+			handler.ResetFunc = func(context.Context, *bbolt.Tx) error {
+				called = true
+				return nil
+			}
+
+			err := resource.ResetHandler(ctx, adaptor)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(called).To(BeTrue())
+		})
+
+		It("does not remove the resource versions if the handler's Reset() method fails", func() {
+			err := resource.StoreVersion(ctx, adaptor, []byte("<resource>"), []byte("<version>"))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			handler.ResetFunc = func(context.Context, *bbolt.Tx) error {
+				return errors.New("<error>")
+			}
+
+			err = resource.ResetHandler(ctx, adaptor)
+			Expect(err).To(MatchError("<error>"))
+
+			ver, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ver).To(Equal([]byte("<version>")))
+		})
+	})
+
+	Describe("func CheckHealth()", func() {
+		It("returns nil if the database is healthy", func() {
+			err := resource.CheckHealth(ctx, adaptor)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = resource.StoreVersion(ctx, adaptor, []byte("<resource>"), []byte("<version>"))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = resource.CheckHealth(ctx, adaptor)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns an error if the resource versions key does not refer to a bucket", func() {
+			err := db.Update(func(tx *bbolt.Tx) error {
+				return tx.Cursor().Bucket().Put([]byte("projection_occ"), []byte("<value>"))
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = resource.CheckHealth(ctx, adaptor)
+			Expect(err).To(MatchError(`the "projection_occ" key does not refer to a bucket`))
+		})
+
+		It("returns an error if the database is closed", func() {
+			err := db.Close()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = resource.CheckHealth(ctx, adaptor)
+			Expect(err).To(MatchError(bbolt.ErrDatabaseNotOpen))
+		})
+	})
+
 	Describe("func Compact()", func() {
 		It("forwards to the handler", func() {
 			handler.CompactFunc = func(
@@ -119,4 +180,32 @@ var _ = Describe("type adaptor", func() {
 			Expect(err).To(MatchError("<error>"))
 		})
 	})
+
+	Describe("func WithLogger()", func() {
+		It("logs the handler's operations", func() {
+			var buf bytes.Buffer
+			logger := slog.New(
+				slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			)
+
+			adaptor := New(db, handler, WithLogger(logger))
+
+			ok, err := adaptor.HandleEvent(
+				ctx,
+				[]byte("<resource>"),
+				nil,
+				[]byte("<version>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			err = adaptor.CloseResource(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring(`msg="handled event" handler=<key> resource=<resource> current_version="" next_version=<version> outcome=applied`))
+			Expect(buf.String()).To(ContainSubstring(`msg="closed resource" handler=<key> resource=<resource>`))
+		})
+	})
 })
@@ -0,0 +1,38 @@
+package boltprojection_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/projectionkit/boltprojection"
+	"github.com/dogmatiq/projectionkit/boltprojection/fixtures"
+	"github.com/dogmatiq/projectionkit/conformance"
+	"go.etcd.io/bbolt"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.RunAdaptorTests(
+		t,
+		func(t *testing.T) dogma.ProjectionMessageHandler {
+			db, err := bbolt.Open(
+				filepath.Join(t.TempDir(), "projection.boltdb"),
+				0600,
+				bbolt.DefaultOptions,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() {
+				db.Close()
+			})
+
+			return New(db, &fixtures.MessageHandler{
+				ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+					c.Identity("<projection>", "<key>")
+				},
+			})
+		},
+	)
+}
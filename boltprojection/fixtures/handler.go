@@ -12,6 +12,7 @@ type MessageHandler struct {
 	ConfigureFunc   func(c dogma.ProjectionConfigurer)
 	HandleEventFunc func(context.Context, *bbolt.Tx, dogma.ProjectionEventScope, dogma.Event) error
 	CompactFunc     func(context.Context, *bbolt.DB, dogma.ProjectionCompactScope) error
+	ResetFunc       func(context.Context, *bbolt.Tx) error
 }
 
 // Configure configures the behavior of the engine as it relates to this
@@ -55,3 +56,15 @@ func (h *MessageHandler) Compact(ctx context.Context, db *bbolt.DB, s dogma.Proj
 
 	return nil
 }
+
+// Reset removes all of the projection's data.
+//
+// If h.ResetFunc is non-nil it returns h.ResetFunc(ctx, tx), otherwise it
+// returns nil.
+func (h *MessageHandler) Reset(ctx context.Context, tx *bbolt.Tx) error {
+	if h.ResetFunc != nil {
+		return h.ResetFunc(ctx, tx)
+	}
+
+	return nil
+}
@@ -63,6 +63,22 @@ type MessageHandler interface {
 	Compact(ctx context.Context, db *bbolt.DB, s dogma.ProjectionCompactScope) error
 }
 
+// ResettableMessageHandler is a MessageHandler that removes its projection
+// data when its resource versions are reset.
+//
+// See resource.ResetHandler().
+type ResettableMessageHandler interface {
+	MessageHandler
+
+	// Reset removes all of the projection's data, such that it can be rebuilt
+	// from the beginning.
+	//
+	// Changes to the projection state MUST be performed within the supplied
+	// transaction, which is the same transaction that removes the handler's
+	// resource versions.
+	Reset(ctx context.Context, tx *bbolt.Tx) error
+}
+
 // NoCompactBehavior can be embedded in MessageHandler implementations to
 // indicate that the projection does not require its data to be compacted.
 //
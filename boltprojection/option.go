@@ -0,0 +1,18 @@
+package boltprojection
+
+import "log/slog"
+
+// An Option configures the optional behavior of a BoltDB projection.
+type Option struct {
+	applyToAdaptor func(*adaptor)
+}
+
+// WithLogger returns an Option that causes the projection to log its
+// operations to l.
+func WithLogger(l *slog.Logger) Option {
+	return Option{
+		applyToAdaptor: func(a *adaptor) {
+			a.logger = l
+		},
+	}
+}
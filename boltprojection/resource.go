@@ -3,6 +3,8 @@ package boltprojection
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"iter"
 
 	"github.com/dogmatiq/projectionkit/resource"
 	"go.etcd.io/bbolt"
@@ -11,18 +13,27 @@ import (
 // ResourceRepository is an implementation of resource.Repository that stores
 // resources versions in a BoltDB database.
 type ResourceRepository struct {
-	db  *bbolt.DB
-	key string
+	db    *bbolt.DB
+	key   string
+	reset func(context.Context, *bbolt.Tx) error
 }
 
-var _ resource.Repository = (*ResourceRepository)(nil)
+var (
+	_ resource.ListableRepository   = (*ResourceRepository)(nil)
+	_ resource.ResettableRepository = (*ResourceRepository)(nil)
+	_ resource.RenamableRepository  = (*ResourceRepository)(nil)
+	_ resource.CheckableRepository  = (*ResourceRepository)(nil)
+)
 
 // NewResourceRepository returns a new BoltDB resource repository.
 func NewResourceRepository(
 	db *bbolt.DB,
 	key string,
 ) *ResourceRepository {
-	return &ResourceRepository{db, key}
+	return &ResourceRepository{
+		db:  db,
+		key: key,
+	}
 }
 
 // ResourceVersion returns the version of the resource r.
@@ -133,6 +144,161 @@ func (rr *ResourceRepository) DeleteResource(_ context.Context, r []byte) error
 	})
 }
 
+// DeleteAllResources removes all information about all resources.
+//
+// If the repository belongs to a handler created by New() that implements
+// ResettableMessageHandler, the handler's Reset() method is called within the
+// same transaction.
+func (rr *ResourceRepository) DeleteAllResources(ctx context.Context) error {
+	return rr.db.Update(func(tx *bbolt.Tx) error {
+		if tb := tx.Bucket(topBucket); tb != nil {
+			err := tb.DeleteBucket([]byte(rr.key))
+			if err != nil && err != bbolt.ErrBucketNotFound {
+				// CODE COVERAGE: This branch can not be easily covered without somehow
+				// breaking the BoltDB connection or the database file in some way.
+				return err
+			}
+		}
+
+		if rr.reset != nil {
+			return rr.reset(ctx, tx)
+		}
+
+		return nil
+	})
+}
+
+// RenameHandlerKey moves the versions of all resources stored for the handler
+// key o such that they are stored for the handler key n instead.
+//
+// The resource versions are moved within a single transaction.
+func (rr *ResourceRepository) RenameHandlerKey(_ context.Context, o, n string) error {
+	return rr.db.Update(func(tx *bbolt.Tx) error {
+		src := handlerBucket(tx, o)
+		if src == nil {
+			return nil
+		}
+
+		if b := handlerBucket(tx, n); b != nil {
+			if k, _ := b.Cursor().First(); k != nil {
+				return resource.ErrHandlerKeyInUse
+			}
+		}
+
+		dst, err := makeHandlerBucket(tx, n)
+		if err != nil {
+			// CODE COVERAGE: This branch can not be easily covered without somehow
+			// breaking the BoltDB connection or the database file in some way.
+			return err
+		}
+
+		if err := src.ForEach(dst.Put); err != nil {
+			// CODE COVERAGE: This branch can not be easily covered without somehow
+			// breaking the BoltDB connection or the database file in some way.
+			return err
+		}
+
+		return tx.Bucket(topBucket).DeleteBucket([]byte(o))
+	})
+}
+
+// CheckHealth returns an error if the database can not be read, or if the
+// key used to store resource versions holds a value instead of a bucket.
+//
+// The bucket that holds the resource versions is created when the first
+// version is stored, so its absence is not considered an error.
+func (rr *ResourceRepository) CheckHealth(_ context.Context) error {
+	return rr.db.View(func(tx *bbolt.Tx) error {
+		k, v := tx.Cursor().Seek(topBucket)
+
+		if bytes.Equal(k, topBucket) && v != nil {
+			return fmt.Errorf("the %q key does not refer to a bucket", topBucket)
+		}
+
+		return nil
+	})
+}
+
+// ListResources returns an iterator over the resources in the repository and
+// their current versions.
+//
+// The resources are yielded in lexicographical order. Each page of resources
+// is read in a separate read-only transaction, which is closed before any
+// resources are yielded.
+func (rr *ResourceRepository) ListResources(ctx context.Context) iter.Seq2[resource.Item, error] {
+	return func(yield func(resource.Item, error) bool) {
+		var after []byte
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(resource.Item{}, err)
+				return
+			}
+
+			var items []resource.Item
+
+			if err := rr.db.View(func(tx *bbolt.Tx) error {
+				items = listResources(tx, rr.key, after, listPageSize)
+				return nil
+			}); err != nil {
+				// CODE COVERAGE: This branch can not be easily covered without somehow
+				// breaking the BoltDB connection or the database file in some way.
+				yield(resource.Item{}, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if len(items) < listPageSize {
+				return
+			}
+
+			after = items[len(items)-1].Resource
+		}
+	}
+}
+
+// listPageSize is the maximum number of resources that are read within a
+// single transaction by ResourceRepository.ListResources().
+const listPageSize = 100
+
+// listResources returns up to n resources for the given handler key that sort
+// after the resource named by after.
+//
+// If after is nil the resources are read from the beginning of the bucket.
+func listResources(tx *bbolt.Tx, hk string, after []byte, n int) []resource.Item {
+	b := handlerBucket(tx, hk)
+	if b == nil {
+		return nil
+	}
+
+	var items []resource.Item
+	c := b.Cursor()
+
+	k, v := c.First()
+	if after != nil {
+		k, v = c.Seek(after)
+		if bytes.Equal(k, after) {
+			k, v = c.Next()
+		}
+	}
+
+	for ; k != nil && len(items) < n; k, v = c.Next() {
+		// The memory referenced by k and v is only valid for the lifetime of
+		// the transaction.
+		items = append(items, resource.Item{
+			Resource: bytes.Clone(k),
+			Version:  bytes.Clone(v),
+		})
+	}
+
+	return items
+}
+
 var (
 	// topBucket is the bucket at the root level that contains all data related
 	// to projection OCC.
@@ -0,0 +1,377 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/resource"
+)
+
+// RunAdaptorTests runs tests that verify the behavior of a
+// dogma.ProjectionMessageHandler implementation.
+//
+// newHandler is called at the start of each test. It must return a handler
+// that uses storage that is not shared with any other handler returned by
+// newHandler. It may use t.Cleanup() to release any resources used by the
+// handler.
+//
+// If the handler implements resource.RepositoryAware, its repository is also
+// tested as per RunRepositoryTests(), along with the behavior of
+// resource.ResetHandler() and resource.RenameHandlerKey() when used with the
+// handler's key.
+func RunAdaptorTests(
+	t *testing.T,
+	newHandler func(t *testing.T) dogma.ProjectionMessageHandler,
+) {
+	t.Helper()
+
+	t.Run("func HandleEvent()", func(t *testing.T) {
+		t.Run("it creates, updates and discards a resource when the OCC parameters are correct", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+			expectVersion(ctx, t, h, "<resource>", "<version 01>")
+
+			handleEvent(ctx, t, h, "<resource>", "<version 01>", "<version 02>", true)
+			expectVersion(ctx, t, h, "<resource>", "<version 02>")
+
+			handleEvent(ctx, t, h, "<resource>", "<version 02>", "", true)
+			expectVersion(ctx, t, h, "<resource>", "")
+		})
+
+		t.Run("it returns false if the current version is incorrect", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+			handleEvent(ctx, t, h, "<resource>", "<incorrect>", "<version 02>", false)
+			expectVersion(ctx, t, h, "<resource>", "<version 01>")
+		})
+
+		t.Run("it returns false if the current version is incorrect when discarding a resource", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+			handleEvent(ctx, t, h, "<resource>", "<incorrect>", "", false)
+			expectVersion(ctx, t, h, "<resource>", "<version 01>")
+		})
+
+		t.Run("it returns false if the resource does not exist and the current version is non-empty", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			handleEvent(ctx, t, h, "<resource>", "<incorrect>", "<version 01>", false)
+			expectVersion(ctx, t, h, "<resource>", "")
+		})
+
+		t.Run("it applies exactly one event when called concurrently with the same current version", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			const n = 10
+			var (
+				g      sync.WaitGroup
+				m      sync.Mutex
+				winner []byte
+				count  int
+			)
+
+			for i := 0; i < n; i++ {
+				g.Add(1)
+				go func() {
+					defer g.Done()
+
+					next := []byte(fmt.Sprintf("<version %02d>", i))
+					ok, err := h.HandleEvent(ctx, []byte("<resource>"), nil, next, nil, stubs.EventA1)
+					if err != nil {
+						t.Errorf("unexpected error: %s", err)
+						return
+					}
+
+					if ok {
+						m.Lock()
+						winner = next
+						count++
+						m.Unlock()
+					}
+				}()
+			}
+
+			g.Wait()
+
+			if count != 1 {
+				t.Fatalf("expected exactly 1 call to succeed, %d succeeded", count)
+			}
+
+			expectVersion(ctx, t, h, "<resource>", string(winner))
+		})
+
+		t.Run("it serializes concurrent updates to the same resource", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			const (
+				workers   = 5
+				perWorker = 5
+			)
+
+			var (
+				g       sync.WaitGroup
+				m       sync.Mutex
+				applied = map[string]bool{}
+			)
+
+			for i := 0; i < workers; i++ {
+				g.Add(1)
+				go func() {
+					defer g.Done()
+
+					for n := 0; n < perWorker; {
+						current, err := h.ResourceVersion(ctx, []byte("<resource>"))
+						if err != nil {
+							t.Errorf("unexpected error: %s", err)
+							return
+						}
+
+						next := []byte(strconv.Itoa(parseCounter(current) + 1))
+
+						ok, err := h.HandleEvent(ctx, []byte("<resource>"), current, next, nil, stubs.EventA1)
+						if err != nil {
+							t.Errorf("unexpected error: %s", err)
+							return
+						}
+
+						if ok {
+							m.Lock()
+							if applied[string(current)] {
+								t.Errorf("more than one event was applied with current version %q", current)
+							}
+							applied[string(current)] = true
+							m.Unlock()
+
+							n++
+						}
+					}
+				}()
+			}
+
+			g.Wait()
+
+			expectVersion(ctx, t, h, "<resource>", strconv.Itoa(workers*perWorker))
+		})
+	})
+
+	t.Run("func ResourceVersion()", func(t *testing.T) {
+		t.Run("it returns an empty version if the resource does not exist", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			expectVersion(ctx, t, h, "<resource>", "")
+		})
+
+		t.Run("it does not confuse resources that share a prefix", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+			handleEvent(ctx, t, h, "<resource>x", "", "<version 02>", true)
+
+			expectVersion(ctx, t, h, "<resource>", "<version 01>")
+			expectVersion(ctx, t, h, "<resource>x", "<version 02>")
+		})
+	})
+
+	t.Run("func CloseResource()", func(t *testing.T) {
+		t.Run("it removes the resource version", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+
+			if err := h.CloseResource(ctx, []byte("<resource>")); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expectVersion(ctx, t, h, "<resource>", "")
+		})
+
+		t.Run("it does not return an error if the resource does not exist", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			if err := h.CloseResource(ctx, []byte("<resource>")); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	})
+
+	t.Run("func Compact()", func(t *testing.T) {
+		t.Run("it does not return an error if there is no data", func(t *testing.T) {
+			ctx := context.Background()
+			h := newHandler(t)
+
+			if err := h.Compact(ctx, nil); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	})
+
+	if _, ok := newHandler(t).(resource.RepositoryAware); ok {
+		t.Run("type resource.Repository", func(t *testing.T) {
+			RunRepositoryTests(
+				t,
+				func(t *testing.T) resource.Repository {
+					h := newHandler(t).(resource.RepositoryAware)
+
+					repo, err := h.ResourceRepository(context.Background())
+					if err != nil {
+						t.Fatalf("unable to obtain resource repository: %s", err)
+					}
+
+					return repo
+				},
+			)
+		})
+
+		t.Run("func resource.ResetHandler()", func(t *testing.T) {
+			t.Run("it allows resources to be recreated", func(t *testing.T) {
+				ctx := context.Background()
+				h := newHandler(t)
+
+				handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+
+				if err := resource.ResetHandler(ctx, h); err != nil {
+					if errors.Is(err, resource.ErrNotSupported) {
+						t.Skip("the handler does not support resetting")
+					}
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				expectVersion(ctx, t, h, "<resource>", "")
+				handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+			})
+		})
+
+		t.Run("func resource.RenameHandlerKey()", func(t *testing.T) {
+			t.Run("it moves the resource versions to the new key", func(t *testing.T) {
+				ctx := context.Background()
+				h := newHandler(t)
+				repo, key := renamableRepository(ctx, t, h)
+
+				handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+
+				renameHandlerKey(ctx, t, repo, key, "<renamed>")
+				expectVersion(ctx, t, h, "<resource>", "")
+
+				renameHandlerKey(ctx, t, repo, "<renamed>", key)
+				expectVersion(ctx, t, h, "<resource>", "<version 01>")
+			})
+
+			t.Run("it returns ErrHandlerKeyInUse if the new key is already in use", func(t *testing.T) {
+				ctx := context.Background()
+				h := newHandler(t)
+				repo, key := renamableRepository(ctx, t, h)
+
+				handleEvent(ctx, t, h, "<resource>", "", "<version 01>", true)
+				renameHandlerKey(ctx, t, repo, key, "<renamed>")
+				handleEvent(ctx, t, h, "<resource>", "", "<version 02>", true)
+
+				err := repo.RenameHandlerKey(ctx, key, "<renamed>")
+				if !errors.Is(err, resource.ErrHandlerKeyInUse) {
+					t.Fatalf("RenameHandlerKey() returned %v, expected %v", err, resource.ErrHandlerKeyInUse)
+				}
+
+				expectVersion(ctx, t, h, "<resource>", "<version 02>")
+			})
+		})
+	}
+}
+
+// renamableRepository returns the repository used by h, along with h's key.
+//
+// It skips the test if the repository does not implement
+// resource.RenamableRepository.
+func renamableRepository(
+	ctx context.Context,
+	t *testing.T,
+	h dogma.ProjectionMessageHandler,
+) (resource.RenamableRepository, string) {
+	t.Helper()
+
+	repo, err := h.(resource.RepositoryAware).ResourceRepository(ctx)
+	if err != nil {
+		t.Fatalf("unable to obtain resource repository: %s", err)
+	}
+
+	r, ok := repo.(resource.RenamableRepository)
+	if !ok {
+		t.Skip("the repository does not support renaming handler keys")
+	}
+
+	return r, identity.Key(h)
+}
+
+// renameHandlerKey calls repo.RenameHandlerKey() and fails the test if it
+// returns an error.
+func renameHandlerKey(
+	ctx context.Context,
+	t *testing.T,
+	repo resource.RenamableRepository,
+	o, n string,
+) {
+	t.Helper()
+
+	if err := repo.RenameHandlerKey(ctx, o, n); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// handleEvent calls h.HandleEvent() and fails the test if the result is not
+// as expected.
+func handleEvent(
+	ctx context.Context,
+	t *testing.T,
+	h dogma.ProjectionMessageHandler,
+	r, c, n string,
+	expect bool,
+) {
+	t.Helper()
+
+	ok, err := h.HandleEvent(ctx, []byte(r), bytesOrNil(c), bytesOrNil(n), nil, stubs.EventA1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if ok != expect {
+		t.Fatalf(
+			"HandleEvent(%q, %q, %q) returned %t, expected %t",
+			r, c, n,
+			ok,
+			expect,
+		)
+	}
+}
+
+// parseCounter parses a resource version that contains a decimal integer. An
+// empty version is treated as zero.
+func parseCounter(v []byte) int {
+	if len(v) == 0 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(string(v))
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
@@ -0,0 +1,8 @@
+// Package conformance provides tests that verify that a projection message
+// handler adaptor or resource repository behaves as expected by the Dogma
+// engine and by the other packages in projectionkit.
+//
+// It is intended for use by the authors of adaptors that are not part of
+// projectionkit. The tests use the standard testing package and do not depend
+// on any particular test framework.
+package conformance
@@ -0,0 +1,390 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"testing"
+
+	"github.com/dogmatiq/projectionkit/resource"
+)
+
+// RunRepositoryTests runs tests that verify the behavior of a
+// resource.Repository implementation.
+//
+// newRepository is called at the start of each test. It must return a
+// repository that uses storage that is not shared with any other repository
+// returned by newRepository. It may use t.Cleanup() to release any resources
+// used by the repository.
+//
+// If the repository implements resource.ListableRepository,
+// resource.ResettableRepository or resource.RenamableRepository the additional
+// methods are also tested.
+func RunRepositoryTests(
+	t *testing.T,
+	newRepository func(t *testing.T) resource.Repository,
+) {
+	t.Helper()
+
+	empty := []struct {
+		desc string
+		v    []byte
+	}{
+		{"nil", nil},
+		{"zero-length", []byte{}},
+	}
+
+	t.Run("func ResourceVersion()", func(t *testing.T) {
+		t.Run("it returns an empty version if the resource does not exist", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			expectVersion(ctx, t, repo, "<resource>", "")
+		})
+	})
+
+	t.Run("func StoreResourceVersion()", func(t *testing.T) {
+		t.Run("it stores the version of a resource that does not exist", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			storeVersion(ctx, t, repo, "<resource>", []byte("<version>"))
+			expectVersion(ctx, t, repo, "<resource>", "<version>")
+		})
+
+		t.Run("it replaces the version of a resource that exists", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			storeVersion(ctx, t, repo, "<resource>", []byte("<version>"))
+			storeVersion(ctx, t, repo, "<resource>", []byte("<next>"))
+			expectVersion(ctx, t, repo, "<resource>", "<next>")
+		})
+
+		for _, e := range empty {
+			desc, v := e.desc, e.v
+
+			t.Run(fmt.Sprintf("it removes the resource when given a %s version", desc), func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				storeVersion(ctx, t, repo, "<resource>", []byte("<version>"))
+				storeVersion(ctx, t, repo, "<resource>", v)
+				expectVersion(ctx, t, repo, "<resource>", "")
+			})
+
+			t.Run(fmt.Sprintf("it does not return an error when given a %s version for a resource that does not exist", desc), func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				storeVersion(ctx, t, repo, "<resource>", v)
+				expectVersion(ctx, t, repo, "<resource>", "")
+			})
+		}
+	})
+
+	t.Run("func UpdateResourceVersion()", func(t *testing.T) {
+		for _, e := range empty {
+			desc, c := e.desc, e.v
+
+			t.Run(fmt.Sprintf("it creates the resource when the current version is %s", desc), func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				updateVersion(ctx, t, repo, "<resource>", c, []byte("<version>"), true)
+				expectVersion(ctx, t, repo, "<resource>", "<version>")
+			})
+
+			t.Run(fmt.Sprintf("it does not update an existing resource when the current version is %s", desc), func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				storeVersion(ctx, t, repo, "<resource>", []byte("<version>"))
+				updateVersion(ctx, t, repo, "<resource>", c, []byte("<next>"), false)
+				expectVersion(ctx, t, repo, "<resource>", "<version>")
+			})
+
+			t.Run(fmt.Sprintf("it removes the resource when the next version is %s", desc), func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				storeVersion(ctx, t, repo, "<resource>", []byte("<version>"))
+				updateVersion(ctx, t, repo, "<resource>", []byte("<version>"), c, true)
+				expectVersion(ctx, t, repo, "<resource>", "")
+			})
+		}
+
+		t.Run("it updates the version when the current version is correct", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			storeVersion(ctx, t, repo, "<resource>", []byte("<version>"))
+			updateVersion(ctx, t, repo, "<resource>", []byte("<version>"), []byte("<next>"), true)
+			expectVersion(ctx, t, repo, "<resource>", "<next>")
+		})
+
+		t.Run("it does not update the version when the current version is incorrect", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			storeVersion(ctx, t, repo, "<resource>", []byte("<version>"))
+			updateVersion(ctx, t, repo, "<resource>", []byte("<incorrect>"), []byte("<next>"), false)
+			expectVersion(ctx, t, repo, "<resource>", "<version>")
+		})
+
+		t.Run("it does not create the resource when the current version is non-empty", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			updateVersion(ctx, t, repo, "<resource>", []byte("<incorrect>"), []byte("<version>"), false)
+			expectVersion(ctx, t, repo, "<resource>", "")
+		})
+	})
+
+	t.Run("func DeleteResource()", func(t *testing.T) {
+		t.Run("it removes the resource", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			storeVersion(ctx, t, repo, "<resource>", []byte("<version>"))
+
+			if err := repo.DeleteResource(ctx, []byte("<resource>")); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expectVersion(ctx, t, repo, "<resource>", "")
+		})
+
+		t.Run("it does not return an error if the resource does not exist", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			if err := repo.DeleteResource(ctx, []byte("<resource>")); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+
+		t.Run("it does not affect other resources", func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepository(t)
+
+			storeVersion(ctx, t, repo, "<resource-a>", []byte("<version>"))
+			storeVersion(ctx, t, repo, "<resource-b>", []byte("<version>"))
+
+			if err := repo.DeleteResource(ctx, []byte("<resource-a>")); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expectVersion(ctx, t, repo, "<resource-b>", "<version>")
+		})
+	})
+
+	if _, ok := newRepository(t).(resource.ListableRepository); ok {
+		t.Run("func ListResources()", func(t *testing.T) {
+			t.Run("it yields each resource with a non-empty version", func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				storeVersion(ctx, t, repo, "<resource-a>", []byte("<version-a>"))
+				storeVersion(ctx, t, repo, "<resource-b>", []byte("<version-b>"))
+				storeVersion(ctx, t, repo, "<resource-c>", []byte("<version-c>"))
+				storeVersion(ctx, t, repo, "<resource-c>", nil)
+
+				actual := map[string]string{}
+				for item, err := range repo.(resource.ListableRepository).ListResources(ctx) {
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					actual[string(item.Resource)] = string(item.Version)
+				}
+
+				expect := map[string]string{
+					"<resource-a>": "<version-a>",
+					"<resource-b>": "<version-b>",
+				}
+
+				if !maps.Equal(actual, expect) {
+					t.Fatalf("ListResources() yielded %v, expected %v", actual, expect)
+				}
+			})
+
+			t.Run("it yields nothing if there are no resources", func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				for item, err := range repo.(resource.ListableRepository).ListResources(ctx) {
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					t.Fatalf("unexpected resource %q", item.Resource)
+				}
+			})
+
+			t.Run("it yields resources that span multiple pages", func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				expect := map[string]string{}
+				for i := 0; i < 250; i++ {
+					r := fmt.Sprintf("<resource %03d>", i)
+					v := fmt.Sprintf("<version %03d>", i)
+					expect[r] = v
+
+					storeVersion(ctx, t, repo, r, []byte(v))
+				}
+
+				actual := map[string]string{}
+				for item, err := range repo.(resource.ListableRepository).ListResources(ctx) {
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					actual[string(item.Resource)] = string(item.Version)
+				}
+
+				if !maps.Equal(actual, expect) {
+					t.Fatalf("ListResources() yielded %d resources, expected %d", len(actual), len(expect))
+				}
+			})
+
+			t.Run("it stops when the consumer stops iterating", func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				storeVersion(ctx, t, repo, "<resource-a>", []byte("<version>"))
+				storeVersion(ctx, t, repo, "<resource-b>", []byte("<version>"))
+				storeVersion(ctx, t, repo, "<resource-c>", []byte("<version>"))
+
+				count := 0
+				for _, err := range repo.(resource.ListableRepository).ListResources(ctx) {
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					count++
+					break
+				}
+
+				if count != 1 {
+					t.Fatalf("ListResources() yielded %d resources after iteration stopped, expected 1", count)
+				}
+			})
+		})
+	}
+
+	if _, ok := newRepository(t).(resource.ResettableRepository); ok {
+		t.Run("func DeleteAllResources()", func(t *testing.T) {
+			t.Run("it removes all resources", func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				storeVersion(ctx, t, repo, "<resource-a>", []byte("<version>"))
+				storeVersion(ctx, t, repo, "<resource-b>", []byte("<version>"))
+
+				if err := repo.(resource.ResettableRepository).DeleteAllResources(ctx); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				expectVersion(ctx, t, repo, "<resource-a>", "")
+				expectVersion(ctx, t, repo, "<resource-b>", "")
+			})
+
+			t.Run("it does not return an error if there are no resources", func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				if err := repo.(resource.ResettableRepository).DeleteAllResources(ctx); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			})
+		})
+	}
+
+	if _, ok := newRepository(t).(resource.RenamableRepository); ok {
+		t.Run("func RenameHandlerKey()", func(t *testing.T) {
+			t.Run("it does not return an error if there are no resources stored for the old key", func(t *testing.T) {
+				ctx := context.Background()
+				repo := newRepository(t)
+
+				if err := repo.(resource.RenamableRepository).RenameHandlerKey(ctx, "<unused>", "<renamed>"); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			})
+		})
+	}
+}
+
+// storeVersion calls repo.StoreResourceVersion() and fails the test if it
+// returns an error.
+func storeVersion(
+	ctx context.Context,
+	t *testing.T,
+	repo resource.Repository,
+	r string,
+	v []byte,
+) {
+	t.Helper()
+
+	if err := repo.StoreResourceVersion(ctx, []byte(r), v); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// updateVersion calls repo.UpdateResourceVersion() and fails the test if the
+// result is not as expected.
+func updateVersion(
+	ctx context.Context,
+	t *testing.T,
+	repo resource.Repository,
+	r string,
+	c, n []byte,
+	expect bool,
+) {
+	t.Helper()
+
+	ok, err := repo.UpdateResourceVersion(ctx, []byte(r), c, n)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if ok != expect {
+		t.Fatalf(
+			"UpdateResourceVersion(%q, %q, %q) returned %t, expected %t",
+			r, c, n,
+			ok,
+			expect,
+		)
+	}
+}
+
+// versionSource is the subset of resource.Repository and
+// dogma.ProjectionMessageHandler used to read resource versions.
+type versionSource interface {
+	ResourceVersion(ctx context.Context, r []byte) ([]byte, error)
+}
+
+// expectVersion fails the test if the version of r is not v.
+func expectVersion(
+	ctx context.Context,
+	t *testing.T,
+	s versionSource,
+	r, v string,
+) {
+	t.Helper()
+
+	actual, err := s.ResourceVersion(ctx, []byte(r))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(actual, []byte(v)) {
+		t.Fatalf("the version of %q is %q, expected %q", r, actual, v)
+	}
+}
+
+// bytesOrNil returns s as a byte-slice, or nil if s is empty.
+func bytesOrNil(s string) []byte {
+	if s == "" {
+		return nil
+	}
+	return []byte(s)
+}
@@ -2,10 +2,12 @@ package dynamoprojection
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/dogmatiq/dogma"
 	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/internal/logging"
 	"github.com/dogmatiq/projectionkit/internal/unboundhandler"
 	"github.com/dogmatiq/projectionkit/resource"
 )
@@ -15,7 +17,9 @@ import (
 type adaptor struct {
 	client  *dynamodb.Client
 	handler MessageHandler
+	key     string
 	repo    *ResourceRepository
+	logger  *slog.Logger
 }
 
 // New returns a new Dogma projection message handler by binding a
@@ -43,17 +47,30 @@ func New(
 		}
 	}
 
+	key := identity.Key(h)
+
 	a := &adaptor{
 		client:  c,
 		handler: h,
+		key:     key,
 		repo: NewResourceRepository(
 			c,
-			identity.Key(h),
+			key,
 			t,
 			rrOpts...,
 		),
 	}
 
+	for _, opt := range options {
+		opt.applyOptionToAdaptor(a)
+	}
+
+	if h, ok := h.(ResettableMessageHandler); ok {
+		a.repo.reset = func(ctx context.Context) error {
+			return h.Reset(ctx, c)
+		}
+	}
+
 	return a
 }
 
@@ -69,6 +86,17 @@ func (a *adaptor) HandleEvent(
 	r, c, n []byte,
 	s dogma.ProjectionEventScope,
 	m dogma.Event,
+) (bool, error) {
+	ok, err := a.handleEvent(ctx, r, c, n, s, m)
+	logging.HandleEvent(ctx, a.logger, a.key, r, c, n, ok, err)
+	return ok, err
+}
+
+func (a *adaptor) handleEvent(
+	ctx context.Context,
+	r, c, n []byte,
+	s dogma.ProjectionEventScope,
+	m dogma.Event,
 ) (bool, error) {
 	items, err := a.handler.HandleEvent(ctx, s, m)
 	if err != nil {
@@ -86,12 +114,16 @@ func (a *adaptor) ResourceVersion(ctx context.Context, r []byte) ([]byte, error)
 // CloseResource informs the projection that the resource r will not be
 // used in any future calls to HandleEvent().
 func (a *adaptor) CloseResource(ctx context.Context, r []byte) error {
-	return a.repo.DeleteResource(ctx, r)
+	err := a.repo.DeleteResource(ctx, r)
+	logging.CloseResource(ctx, a.logger, a.key, r, err)
+	return err
 }
 
 // Compact reduces the size of the projection's data.
 func (a *adaptor) Compact(ctx context.Context, s dogma.ProjectionCompactScope) error {
-	return a.handler.Compact(ctx, a.client, s)
+	err := a.handler.Compact(ctx, a.client, s)
+	logging.Compact(ctx, a.logger, a.key, err)
+	return err
 }
 
 // ResourceRepository returns a repository that can be used to manipulate the
@@ -1,8 +1,10 @@
 package dynamoprojection_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"log/slog"
 	"os"
 	"time"
 
@@ -15,8 +17,8 @@ import (
 	. "github.com/dogmatiq/enginekit/enginetest/stubs"
 	. "github.com/dogmatiq/projectionkit/dynamoprojection"
 	"github.com/dogmatiq/projectionkit/dynamoprojection/fixtures" // can't dot-import due to conflict
-	"github.com/dogmatiq/projectionkit/internal/adaptortest"
 	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/resource"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -106,8 +108,6 @@ var _ = Describe("type adaptor", func() {
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 
-	adaptortest.DescribeAdaptor(&ctx, &adaptor)
-
 	Describe("func New()", func() {
 		It("returns an unbound handler if the client is nil", func() {
 			adaptor = New(nil, "ProjectionOCCTable", handler)
@@ -240,6 +240,18 @@ var _ = Describe("type adaptor", func() {
 		})
 	})
 
+	Describe("func ResetHandler()", func() {
+		It("calls the handler's Reset() method", func() {
+			handler.ResetFunc = func(_ context.Context, c *dynamodb.Client) error {
+				Expect(c).To(BeIdenticalTo(client))
+				return errors.New("<error>")
+			}
+
+			err := resource.ResetHandler(ctx, adaptor)
+			Expect(err).To(MatchError("<error>"))
+		})
+	})
+
 	Describe("func Compact()", func() {
 		It("forwards to the handler", func() {
 			handler.CompactFunc = func(
@@ -258,4 +270,32 @@ var _ = Describe("type adaptor", func() {
 			Expect(err).To(MatchError("<error>"))
 		})
 	})
+
+	Describe("func WithLogger()", func() {
+		It("logs the handler's operations", func() {
+			var buf bytes.Buffer
+			logger := slog.New(
+				slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			)
+
+			adaptor := New(client, "ProjectionOCCTable", handler, WithLogger(logger))
+
+			ok, err := adaptor.HandleEvent(
+				ctx,
+				[]byte("<resource>"),
+				nil,
+				[]byte("<version>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			err = adaptor.CloseResource(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring(`msg="handled event" handler=<key> resource=<resource> current_version="" next_version=<version> outcome=applied`))
+			Expect(buf.String()).To(ContainSubstring(`msg="closed resource" handler=<key> resource=<resource>`))
+		})
+	})
 })
@@ -0,0 +1,91 @@
+package dynamoprojection_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/conformance"
+	. "github.com/dogmatiq/projectionkit/dynamoprojection"
+	"github.com/dogmatiq/projectionkit/dynamoprojection/fixtures"
+)
+
+func TestConformance(t *testing.T) {
+	var tables atomic.Int64
+
+	conformance.RunAdaptorTests(
+		t,
+		func(t *testing.T) dogma.ProjectionMessageHandler {
+			ctx := context.Background()
+
+			endpoint := os.Getenv("DOGMATIQ_TEST_DYNAMODB_ENDPOINT")
+			if endpoint == "" {
+				endpoint = "http://localhost:28000"
+			}
+
+			cfg, err := config.LoadDefaultConfig(
+				ctx,
+				config.WithRegion("us-east-1"),
+				config.WithEndpointResolverWithOptions(
+					aws.EndpointResolverWithOptionsFunc(
+						func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+							return aws.Endpoint{URL: endpoint}, nil
+						},
+					),
+				),
+				config.WithCredentialsProvider(
+					credentials.StaticCredentialsProvider{
+						Value: aws.Credentials{
+							AccessKeyID:     "id",
+							SecretAccessKey: "secret",
+							SessionToken:    "",
+						},
+					},
+				),
+				config.WithRetryer(
+					func() aws.Retryer {
+						return aws.NopRetryer{}
+					},
+				),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := dynamodb.NewFromConfig(cfg)
+			table := fmt.Sprintf("ConformanceOCCTable%d", tables.Add(1))
+
+			if err := CreateTable(ctx, client, table); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() {
+				DeleteTable(ctx, client, table)
+			})
+
+			if err := dynamodb.NewTableExistsWaiter(client).Wait(
+				ctx,
+				&dynamodb.DescribeTableInput{
+					TableName: aws.String(table),
+				},
+				5*time.Second,
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			return New(client, table, &fixtures.MessageHandler{
+				ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+					c.Identity("<projection>", "<key>")
+				},
+			})
+		},
+	)
+}
@@ -13,6 +13,7 @@ type MessageHandler struct {
 	ConfigureFunc   func(c dogma.ProjectionConfigurer)
 	HandleEventFunc func(ctx context.Context, s dogma.ProjectionEventScope, m dogma.Event) ([]types.TransactWriteItem, error)
 	CompactFunc     func(context.Context, *dynamodb.Client, dogma.ProjectionCompactScope) error
+	ResetFunc       func(context.Context, *dynamodb.Client) error
 }
 
 // Configure configures the behavior of the engine as it relates to this
@@ -55,3 +56,15 @@ func (h *MessageHandler) Compact(ctx context.Context, client *dynamodb.Client, s
 
 	return nil
 }
+
+// Reset removes all of the projection's data.
+//
+// If h.ResetFunc is non-nil it returns h.ResetFunc(ctx, client), otherwise it
+// returns nil.
+func (h *MessageHandler) Reset(ctx context.Context, client *dynamodb.Client) error {
+	if h.ResetFunc != nil {
+		return h.ResetFunc(ctx, client)
+	}
+
+	return nil
+}
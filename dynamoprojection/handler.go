@@ -63,6 +63,23 @@ type MessageHandler interface {
 	Compact(ctx context.Context, client *dynamodb.Client, s dogma.ProjectionCompactScope) error
 }
 
+// ResettableMessageHandler is a MessageHandler that removes its projection
+// data when its resource versions are reset.
+//
+// See resource.ResetHandler().
+type ResettableMessageHandler interface {
+	MessageHandler
+
+	// Reset removes all of the projection's data, such that it can be rebuilt
+	// from the beginning.
+	//
+	// DynamoDB does not support transactions of an arbitrary size, so Reset()
+	// is called before the handler's resource versions are removed. If the
+	// resource versions can not be removed Reset() is called again the next
+	// time the handler is reset.
+	Reset(ctx context.Context, client *dynamodb.Client) error
+}
+
 // NoCompactBehavior can be embedded in MessageHandler implementations to
 // indicate that the projection does not require its data to be compacted.
 //
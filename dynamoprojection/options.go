@@ -1,13 +1,15 @@
 package dynamoprojection
 
 import (
+	"log/slog"
+
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 )
 
 // HandlerOption is used to alter the behavior of AWS DynamoDB projection
 // handler.
 type HandlerOption interface {
-	applyOptionToAdaptor(*decorators)
+	applyOptionToAdaptor(*adaptor)
 }
 
 // ResourceRepositoryOption is used to alter the behavior of a ResourceRepository.
@@ -26,19 +28,21 @@ type decorators struct {
 	decoratePutItem            func(*dynamodb.PutItemInput) []func(*dynamodb.Options)
 	decorateDeleteItem         func(*dynamodb.DeleteItemInput) []func(*dynamodb.Options)
 	decorateTransactWriteItems func(*dynamodb.TransactWriteItemsInput) []func(*dynamodb.Options)
+	decorateScan               func(*dynamodb.ScanInput) []func(*dynamodb.Options)
+	decorateDescribeTable      func(*dynamodb.DescribeTableInput) []func(*dynamodb.Options)
 	decorateCreateTableItem    func(*dynamodb.CreateTableInput) []func(*dynamodb.Options)
 	decorateDeleteTableItem    func(*dynamodb.DeleteTableInput) []func(*dynamodb.Options)
 }
 
 type options struct {
-	applyOptionToAdaptorFunc          func(*decorators)
+	applyOptionToAdaptorFunc          func(*adaptor)
 	applyResourceRepositoryOptionFunc func(*decorators)
 	applyTableOptionFunc              func(*decorators)
 }
 
-func (o *options) applyOptionToAdaptor(decorators *decorators) {
+func (o *options) applyOptionToAdaptor(a *adaptor) {
 	if o.applyOptionToAdaptorFunc != nil {
-		o.applyOptionToAdaptorFunc(decorators)
+		o.applyOptionToAdaptorFunc(a)
 	}
 }
 
@@ -65,7 +69,7 @@ func WithDecorateGetItem(
 	ResourceRepositoryOption
 } {
 	return &options{
-		applyOptionToAdaptorFunc: func(d *decorators) {},
+		applyOptionToAdaptorFunc: func(*adaptor) {},
 		applyResourceRepositoryOptionFunc: func(d *decorators) {
 			d.decorateGetItem = dec
 		},
@@ -83,7 +87,7 @@ func WithDecoratePutItem(
 	ResourceRepositoryOption
 } {
 	return &options{
-		applyOptionToAdaptorFunc: func(d *decorators) {},
+		applyOptionToAdaptorFunc: func(*adaptor) {},
 		applyResourceRepositoryOptionFunc: func(d *decorators) {
 			d.decoratePutItem = dec
 		},
@@ -101,7 +105,7 @@ func WithDecorateDeleteItem(
 	ResourceRepositoryOption
 } {
 	return &options{
-		applyOptionToAdaptorFunc: func(d *decorators) {},
+		applyOptionToAdaptorFunc: func(*adaptor) {},
 		applyResourceRepositoryOptionFunc: func(d *decorators) {
 			d.decorateDeleteItem = dec
 		},
@@ -125,13 +129,60 @@ func WithDecorateTransactWriteItems(
 	ResourceRepositoryOption
 } {
 	return &options{
-		applyOptionToAdaptorFunc: func(d *decorators) {},
+		applyOptionToAdaptorFunc: func(*adaptor) {},
 		applyResourceRepositoryOptionFunc: func(d *decorators) {
 			d.decorateTransactWriteItems = dec
 		},
 	}
 }
 
+// WithDecorateScan adds a decorator for DynamoDB Scan operations.
+//
+// The decorator function may modify the input structure in-place. It returns a
+// slice of DynamoDB request.Option values that are applied to the API request.
+func WithDecorateScan(
+	dec func(*dynamodb.ScanInput) []func(*dynamodb.Options),
+) interface {
+	HandlerOption
+	ResourceRepositoryOption
+} {
+	return &options{
+		applyOptionToAdaptorFunc: func(*adaptor) {},
+		applyResourceRepositoryOptionFunc: func(d *decorators) {
+			d.decorateScan = dec
+		},
+	}
+}
+
+// WithDecorateDescribeTable adds a decorator for DynamoDB DescribeTable
+// operations.
+//
+// The decorator function may modify the input structure in-place. It returns a
+// slice of DynamoDB request.Option values that are applied to the API request.
+func WithDecorateDescribeTable(
+	dec func(*dynamodb.DescribeTableInput) []func(*dynamodb.Options),
+) interface {
+	HandlerOption
+	ResourceRepositoryOption
+} {
+	return &options{
+		applyOptionToAdaptorFunc: func(*adaptor) {},
+		applyResourceRepositoryOptionFunc: func(d *decorators) {
+			d.decorateDescribeTable = dec
+		},
+	}
+}
+
+// WithLogger returns a HandlerOption that causes the projection to log its
+// operations to l.
+func WithLogger(l *slog.Logger) HandlerOption {
+	return &options{
+		applyOptionToAdaptorFunc: func(a *adaptor) {
+			a.logger = l
+		},
+	}
+}
+
 // WithDecorateCreateTable adds a decorator for DynamoDB CreateTable operations.
 //
 // The decorator function may modify the input structure in-place. It returns a
@@ -450,6 +450,94 @@ var _ = Context("adding options", func() {
 				Expect(err.Error()).To(ContainSubstring("no such host"))
 			})
 		})
+
+		Describe("WithDecorateScan() option", func() {
+			It("can modify the input of the operation", func() {
+				repository := NewResourceRepository(
+					client,
+					identity.Key(handler),
+					"ProjectionOCCTable",
+					WithDecorateScan(
+						func(in *dynamodb.ScanInput) []func(*dynamodb.Options) {
+							in.TableName = aws.String("NonExistingTable")
+							return nil
+						},
+					),
+				)
+
+				for _, err := range repository.ListResources(ctx) {
+					Expect(err).Should(HaveOccurred())
+					Expect(errors.As(err, new(*types.ResourceNotFoundException))).To(BeTrue())
+				}
+			})
+
+			It("can modify the operation via returned options", func() {
+				repository := NewResourceRepository(
+					client,
+					identity.Key(handler),
+					"ProjectionOCCTable",
+					WithDecorateScan(
+						func(*dynamodb.ScanInput) []func(*dynamodb.Options) {
+							return []func(opts *dynamodb.Options){
+								func(opts *dynamodb.Options) {
+									opts.EndpointResolver = dynamodb.EndpointResolverFromURL(
+										"http://non-existing-host.com:8000",
+									)
+								},
+							}
+						},
+					),
+				)
+
+				for _, err := range repository.ListResources(ctx) {
+					Expect(err).Should(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("no such host"))
+				}
+			})
+		})
+
+		Describe("WithDecorateDescribeTable() option", func() {
+			It("can modify the input of the operation", func() {
+				repository := NewResourceRepository(
+					client,
+					identity.Key(handler),
+					"ProjectionOCCTable",
+					WithDecorateDescribeTable(
+						func(in *dynamodb.DescribeTableInput) []func(*dynamodb.Options) {
+							in.TableName = aws.String("NonExistingTable")
+							return nil
+						},
+					),
+				)
+
+				err := repository.CheckHealth(ctx)
+				Expect(err).Should(HaveOccurred())
+				Expect(errors.As(err, new(*types.ResourceNotFoundException))).To(BeTrue())
+			})
+
+			It("can modify the operation via returned options", func() {
+				repository := NewResourceRepository(
+					client,
+					identity.Key(handler),
+					"ProjectionOCCTable",
+					WithDecorateDescribeTable(
+						func(*dynamodb.DescribeTableInput) []func(*dynamodb.Options) {
+							return []func(opts *dynamodb.Options){
+								func(opts *dynamodb.Options) {
+									opts.EndpointResolver = dynamodb.EndpointResolverFromURL(
+										"http://non-existing-host.com:8000",
+									)
+								},
+							}
+						},
+					),
+				)
+
+				err := repository.CheckHealth(ctx)
+				Expect(err).Should(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("no such host"))
+			})
+		})
 	})
 
 	Describe("New() options", func() {
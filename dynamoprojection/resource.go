@@ -1,9 +1,11 @@
 package dynamoprojection
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"iter"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -19,9 +21,15 @@ type ResourceRepository struct {
 	key        string
 	occTable   string
 	decorators *decorators
+	reset      func(context.Context) error
 }
 
-var _ resource.Repository = (*ResourceRepository)(nil)
+var (
+	_ resource.ListableRepository   = (*ResourceRepository)(nil)
+	_ resource.ResettableRepository = (*ResourceRepository)(nil)
+	_ resource.RenamableRepository  = (*ResourceRepository)(nil)
+	_ resource.CheckableRepository  = (*ResourceRepository)(nil)
+)
 
 // NewResourceRepository returns a new DynamoDB resource repository.
 func NewResourceRepository(
@@ -155,6 +163,258 @@ func (rr *ResourceRepository) DeleteResource(ctx context.Context, r []byte) erro
 	return err
 }
 
+// DeleteAllResources removes all information about all resources.
+//
+// DynamoDB does not support transactions of an arbitrary size, so the items
+// are deleted one at a time. If an error occurs some resources may remain,
+// in which case DeleteAllResources() should be called again.
+//
+// If the repository belongs to a handler created by New() that implements
+// ResettableMessageHandler, the handler's Reset() method is called before any
+// resource versions are removed.
+func (rr *ResourceRepository) DeleteAllResources(ctx context.Context) error {
+	if rr.reset != nil {
+		if err := rr.reset(ctx); err != nil {
+			return err
+		}
+	}
+
+	for item, err := range rr.scan(ctx, rr.key) {
+		if err != nil {
+			return err
+		}
+
+		if err := rr.DeleteResource(ctx, item.Resource); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenameHandlerKey moves the versions of all resources stored for the handler
+// key o such that they are stored for the handler key n instead.
+//
+// Each resource is moved using a separate transaction that rewrites its
+// HandlerAndResource attribute. If an error occurs some resources may remain
+// under the old key, in which case RenameHandlerKey() should be called again.
+func (rr *ResourceRepository) RenameHandlerKey(ctx context.Context, o, n string) error {
+	for item, err := range rr.scan(ctx, n) {
+		if err != nil {
+			return err
+		}
+
+		if len(item.Version) != 0 {
+			return resource.ErrHandlerKeyInUse
+		}
+	}
+
+	for item, err := range rr.scan(ctx, o) {
+		if err != nil {
+			return err
+		}
+
+		if err := rr.moveResource(ctx, o, n, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveResource moves a single resource from the handler key o to the handler
+// key n.
+func (rr *ResourceRepository) moveResource(
+	ctx context.Context,
+	o, n string,
+	item resource.Item,
+) error {
+	del := types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName:           aws.String(rr.occTable),
+			ConditionExpression: aws.String(`#V = :V`),
+			ExpressionAttributeNames: map[string]string{
+				"#V": resourceVersionAttr,
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":V": &types.AttributeValueMemberB{
+					Value: item.Version,
+				},
+			},
+			Key: map[string]types.AttributeValue{
+				handlerAndResourceAttr: &types.AttributeValueMemberB{
+					Value: handlerAndResource(o, item.Resource),
+				},
+			},
+		},
+	}
+
+	items := []types.TransactWriteItem{del}
+
+	if len(item.Version) != 0 {
+		items = append(
+			[]types.TransactWriteItem{
+				{
+					Put: &types.Put{
+						TableName:           aws.String(rr.occTable),
+						ConditionExpression: aws.String(`attribute_not_exists(#HR)`),
+						ExpressionAttributeNames: map[string]string{
+							"#HR": handlerAndResourceAttr,
+						},
+						Item: map[string]types.AttributeValue{
+							handlerAndResourceAttr: &types.AttributeValueMemberB{
+								Value: handlerAndResource(n, item.Resource),
+							},
+							resourceVersionAttr: &types.AttributeValueMemberB{
+								Value: item.Version,
+							},
+						},
+					},
+				},
+			},
+			items...,
+		)
+	}
+
+	_, err := awsx.Do(
+		ctx,
+		rr.client.TransactWriteItems,
+		rr.decorators.decorateTransactWriteItems,
+		&dynamodb.TransactWriteItemsInput{
+			TransactItems: items,
+		},
+	)
+
+	if isOCCConflict(err) {
+		return fmt.Errorf(
+			"unable to move resource %q to handler key %q: %w",
+			item.Resource,
+			n,
+			resource.ErrHandlerKeyInUse,
+		)
+	}
+
+	return err
+}
+
+// CheckHealth returns an error if the projection OCC table can not be reached,
+// or is not usable.
+//
+// It verifies that the table is active and that its key schema matches the
+// one created by CreateTable(), then reads a single item from the table.
+func (rr *ResourceRepository) CheckHealth(ctx context.Context) error {
+	out, err := awsx.Do(
+		ctx,
+		rr.client.DescribeTable,
+		rr.decorators.decorateDescribeTable,
+		&dynamodb.DescribeTableInput{
+			TableName: aws.String(rr.occTable),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTable(out.Table); err != nil {
+		return fmt.Errorf("projection OCC table %s %w", rr.occTable, err)
+	}
+
+	_, err = rr.ResourceVersion(ctx, nil)
+	return err
+}
+
+// ListResources returns an iterator over the resources in the repository and
+// their current versions.
+//
+// The resources are found by scanning the projection OCC table, one page at a
+// time. They are yielded in no particular order.
+func (rr *ResourceRepository) ListResources(ctx context.Context) iter.Seq2[resource.Item, error] {
+	return func(yield func(resource.Item, error) bool) {
+		for item, err := range rr.scan(ctx, rr.key) {
+			if len(item.Version) == 0 && err == nil {
+				// StoreResourceVersion() persists empty versions rather
+				// than deleting the item.
+				continue
+			}
+
+			if !yield(item, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// scan returns an iterator over all of the items in the projection OCC table
+// that belong to the handler with the key hk, including those with empty
+// versions.
+func (rr *ResourceRepository) scan(ctx context.Context, hk string) iter.Seq2[resource.Item, error] {
+	return func(yield func(resource.Item, error) bool) {
+		prefix := handlerAndResource(hk, nil)
+
+		in := &dynamodb.ScanInput{
+			TableName:        aws.String(rr.occTable),
+			FilterExpression: aws.String(`begins_with(#HR, :P)`),
+			ExpressionAttributeNames: map[string]string{
+				"#HR": handlerAndResourceAttr,
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":P": &types.AttributeValueMemberB{
+					Value: prefix,
+				},
+			},
+			Limit: aws.Int32(listPageSize),
+		}
+
+		for {
+			out, err := awsx.Do(
+				ctx,
+				rr.client.Scan,
+				rr.decorators.decorateScan,
+				in,
+			)
+			if err != nil {
+				yield(resource.Item{}, err)
+				return
+			}
+
+			for _, item := range out.Items {
+				hr, hrOK := item[handlerAndResourceAttr].(*types.AttributeValueMemberB)
+				v, vOK := item[resourceVersionAttr].(*types.AttributeValueMemberB)
+				if !hrOK || !vOK || !bytes.HasPrefix(hr.Value, prefix) {
+					// CODE COVERAGE: This branch can not be easily covered without somehow
+					// breaking the integrity of the record in the projection OCC table.
+					panic(
+						fmt.Sprintf(
+							"invalid structure in projection OCC table %s",
+							rr.occTable,
+						),
+					)
+				}
+
+				if !yield(
+					resource.Item{
+						Resource: hr.Value[len(prefix):],
+						Version:  v.Value,
+					},
+					nil,
+				) {
+					return
+				}
+			}
+
+			if len(out.LastEvaluatedKey) == 0 {
+				return
+			}
+
+			in.ExclusiveStartKey = out.LastEvaluatedKey
+		}
+	}
+}
+
+// listPageSize is the maximum number of items that are evaluated by each scan
+// of the projection OCC table.
+const listPageSize = 100
+
 // createResourceWithinTx creates a resource record in the projection OCC table
 // and applies the supplied items within a single transaction.
 func (rr *ResourceRepository) createResourceWithinTx(
@@ -3,6 +3,7 @@ package dynamoprojection
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -99,3 +100,28 @@ func DeleteTable(
 
 	return err
 }
+
+// checkTable returns an error if t is not a table that can be used to store
+// projection resource versions.
+func checkTable(t *types.TableDescription) error {
+	switch t.TableStatus {
+	case types.TableStatusActive, types.TableStatusUpdating:
+	default:
+		return fmt.Errorf("is not active (status is %s)", t.TableStatus)
+	}
+
+	if len(t.KeySchema) != 1 ||
+		aws.ToString(t.KeySchema[0].AttributeName) != handlerAndResourceAttr ||
+		t.KeySchema[0].KeyType != types.KeyTypeHash {
+		return fmt.Errorf("does not use %s as its only key", handlerAndResourceAttr)
+	}
+
+	for _, a := range t.AttributeDefinitions {
+		if aws.ToString(a.AttributeName) == handlerAndResourceAttr &&
+			a.AttributeType != types.ScalarAttributeTypeB {
+			return fmt.Errorf("does not use the binary type for %s", handlerAndResourceAttr)
+		}
+	}
+
+	return nil
+}
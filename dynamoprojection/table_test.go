@@ -2,6 +2,7 @@ package dynamoprojection_test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	. "github.com/dogmatiq/projectionkit/dynamoprojection"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -90,4 +92,33 @@ var _ = Context("creating and deleting a table", func() {
 			Expect(err).ShouldNot(HaveOccurred())
 		})
 	})
+
+	Describe("func (*ResourceRepository) CheckHealth()", func() {
+		It("returns nil if the table is usable", func() {
+			err := CreateTable(ctx, client, "ProjectionOCCTable")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = dynamodb.NewTableExistsWaiter(client).Wait(
+				ctx,
+				&dynamodb.DescribeTableInput{
+					TableName: aws.String("ProjectionOCCTable"),
+				},
+				5*time.Second,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			repository := NewResourceRepository(client, "<key>", "ProjectionOCCTable")
+			err = repository.CheckHealth(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = DeleteTable(ctx, client, "ProjectionOCCTable")
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns an error if the table does not exist", func() {
+			repository := NewResourceRepository(client, "<key>", "NonExistingTable")
+			err := repository.CheckHealth(ctx)
+			Expect(errors.As(err, new(*types.ResourceNotFoundException))).To(BeTrue())
+		})
+	})
 })
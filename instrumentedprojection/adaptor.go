@@ -0,0 +1,135 @@
+package instrumentedprojection
+
+import (
+	"context"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/resource"
+	"github.com/dogmatiq/projectionkit/sqlprojection"
+)
+
+// adaptor is an implementation of dogma.ProjectionMessageHandler that records
+// metrics about another handler.
+type adaptor struct {
+	handler  dogma.ProjectionMessageHandler
+	key      string
+	recorder Recorder
+}
+
+// repositoryAwareAdaptor is an adaptor that wraps a handler that implements
+// resource.RepositoryAware.
+type repositoryAwareAdaptor struct {
+	*adaptor
+	repo resource.RepositoryAware
+}
+
+// New returns a Dogma projection message handler that records metrics about
+// the handler h.
+//
+// If h implements resource.RepositoryAware or sqlprojection.BatchHandler, so
+// does the returned handler.
+func New(
+	h dogma.ProjectionMessageHandler,
+	options ...Option,
+) dogma.ProjectionMessageHandler {
+	a := &adaptor{
+		handler: h,
+		key:     identity.Key(h),
+	}
+
+	for _, opt := range options {
+		opt.applyToAdaptor(a)
+	}
+
+	if a.recorder == nil {
+		a.recorder = DefaultRecorder()
+	}
+
+	ra, isRepositoryAware := h.(resource.RepositoryAware)
+	bh, isBatchHandler := h.(sqlprojection.BatchHandler)
+
+	switch {
+	case isRepositoryAware && isBatchHandler:
+		return repositoryAwareBatchAdaptor{batchAdaptor{a, bh}, ra}
+	case isBatchHandler:
+		return batchAdaptor{a, bh}
+	case isRepositoryAware:
+		return repositoryAwareAdaptor{a, ra}
+	default:
+		return a
+	}
+}
+
+// Configure produces a configuration for this handler by calling methods on
+// the configurer c.
+func (a *adaptor) Configure(c dogma.ProjectionConfigurer) {
+	a.handler.Configure(c)
+}
+
+// HandleEvent updates the projection to reflect the occurrence of an event.
+func (a *adaptor) HandleEvent(
+	ctx context.Context,
+	r, c, n []byte,
+	s dogma.ProjectionEventScope,
+	m dogma.Event,
+) (bool, error) {
+	start := time.Now()
+	ok, err := a.handler.HandleEvent(ctx, r, c, n, s, m)
+	a.recordHandleEvent(start, 1, ok, err)
+	return ok, err
+}
+
+// recordHandleEvent records the outcome of a call that began at start and
+// attempted to apply n events.
+func (a *adaptor) recordHandleEvent(start time.Time, n int, ok bool, err error) {
+	a.recorder.ObserveDuration(a.key, HandleEventDuration, time.Since(start))
+
+	if err != nil {
+		a.recorder.IncrementCounter(a.key, Errors)
+	} else if ok {
+		for i := 0; i < n; i++ {
+			a.recorder.IncrementCounter(a.key, EventsHandled)
+		}
+	} else {
+		a.recorder.IncrementCounter(a.key, OCCConflicts)
+	}
+}
+
+// ResourceVersion returns the version of the resource r.
+func (a *adaptor) ResourceVersion(ctx context.Context, r []byte) ([]byte, error) {
+	v, err := a.handler.ResourceVersion(ctx, r)
+	a.countError(err)
+	return v, err
+}
+
+// CloseResource informs the projection that the resource r will not be
+// used in any future calls to HandleEvent().
+func (a *adaptor) CloseResource(ctx context.Context, r []byte) error {
+	err := a.handler.CloseResource(ctx, r)
+	a.countError(err)
+	return err
+}
+
+// Compact reduces the size of the projection's data.
+func (a *adaptor) Compact(ctx context.Context, s dogma.ProjectionCompactScope) error {
+	start := time.Now()
+	err := a.handler.Compact(ctx, s)
+	a.recorder.ObserveDuration(a.key, CompactDuration, time.Since(start))
+	a.countError(err)
+	return err
+}
+
+// countError increments the Errors counter if err is non-nil.
+func (a *adaptor) countError(err error) {
+	if err != nil {
+		a.recorder.IncrementCounter(a.key, Errors)
+	}
+}
+
+// ResourceRepository returns a repository that can be used to manipulate the
+// handler's resource versions.
+func (a repositoryAwareAdaptor) ResourceRepository(ctx context.Context) (resource.Repository, error) {
+	return a.repo.ResourceRepository(ctx)
+}
@@ -0,0 +1,360 @@
+package instrumentedprojection_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/projectionkit/instrumentedprojection"
+	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/memoryprojection"
+	"github.com/dogmatiq/projectionkit/memoryprojection/fixtures"
+	"github.com/dogmatiq/projectionkit/resource"
+	"github.com/dogmatiq/projectionkit/sqlprojection"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type adaptor", func() {
+	var (
+		ctx      context.Context
+		recorder *recorderStub
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		recorder = &recorderStub{}
+	})
+
+	When("the handler implements resource.RepositoryAware", func() {
+		var adaptor dogma.ProjectionMessageHandler
+
+		BeforeEach(func() {
+			adaptor = New(
+				&memoryprojection.Projection[int, *fixtures.MessageHandler[int]]{
+					Handler: &fixtures.MessageHandler[int]{
+						ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+							c.Identity("<projection>", "<key>")
+						},
+					},
+				},
+				WithRecorder(recorder),
+			)
+		})
+
+		It("implements resource.RepositoryAware", func() {
+			_, ok := adaptor.(resource.RepositoryAware)
+			Expect(ok).To(BeTrue())
+
+			err := resource.StoreVersion(ctx, adaptor, []byte("<resource>"), []byte("<version>"))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			ver, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ver).To(Equal([]byte("<version>")))
+		})
+	})
+
+	When("the handler implements sqlprojection.BatchHandler", func() {
+		var (
+			handler *batchHandlerStub
+			adaptor dogma.ProjectionMessageHandler
+		)
+
+		BeforeEach(func() {
+			handler = &batchHandlerStub{
+				ProjectionMessageHandlerStub: &ProjectionMessageHandlerStub{
+					ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+						c.Identity("<projection>", "<key>")
+					},
+				},
+			}
+
+			adaptor = New(handler, WithRecorder(recorder))
+		})
+
+		It("implements sqlprojection.BatchHandler", func() {
+			_, ok := adaptor.(sqlprojection.BatchHandler)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("does not implement resource.RepositoryAware", func() {
+			_, ok := adaptor.(resource.RepositoryAware)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("implements resource.RepositoryAware if the handler does", func() {
+			adaptor = New(
+				repositoryAwareBatchHandlerStub{handler},
+				WithRecorder(recorder),
+			)
+
+			_, ok := adaptor.(sqlprojection.BatchHandler)
+			Expect(ok).To(BeTrue())
+
+			_, ok = adaptor.(resource.RepositoryAware)
+			Expect(ok).To(BeTrue())
+		})
+
+		Describe("func HandleEventBatch()", func() {
+			events := []sqlprojection.BatchEvent{
+				{Event: EventA1},
+				{Event: EventA2},
+			}
+
+			It("forwards to the handler and records each handled event", func() {
+				handler.HandleEventBatchFunc = func(
+					_ context.Context,
+					r, c, n []byte,
+					e []sqlprojection.BatchEvent,
+				) (bool, error) {
+					Expect(r).To(Equal([]byte("<resource>")))
+					Expect(e).To(Equal(events))
+					return true, nil
+				}
+
+				ok, err := adaptor.(sqlprojection.BatchHandler).HandleEventBatch(
+					ctx,
+					[]byte("<resource>"),
+					nil,
+					[]byte("<version>"),
+					events,
+				)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{EventsHandled: 2}))
+				Expect(recorder.histograms[HandleEventDuration]).To(HaveLen(1))
+			})
+
+			It("records an OCC conflict", func() {
+				handler.HandleEventBatchFunc = func(
+					context.Context,
+					[]byte, []byte, []byte,
+					[]sqlprojection.BatchEvent,
+				) (bool, error) {
+					return false, nil
+				}
+
+				ok, err := adaptor.(sqlprojection.BatchHandler).HandleEventBatch(ctx, nil, nil, nil, events)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{OCCConflicts: 1}))
+			})
+
+			It("records an error", func() {
+				handler.HandleEventBatchFunc = func(
+					context.Context,
+					[]byte, []byte, []byte,
+					[]sqlprojection.BatchEvent,
+				) (bool, error) {
+					return false, errors.New("<error>")
+				}
+
+				_, err := adaptor.(sqlprojection.BatchHandler).HandleEventBatch(ctx, nil, nil, nil, events)
+				Expect(err).To(MatchError("<error>"))
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{Errors: 1}))
+			})
+		})
+	})
+
+	When("the handler does not implement resource.RepositoryAware", func() {
+		var (
+			handler *ProjectionMessageHandlerStub
+			adaptor dogma.ProjectionMessageHandler
+		)
+
+		BeforeEach(func() {
+			handler = &ProjectionMessageHandlerStub{
+				ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+					c.Identity("<projection>", "<key>")
+				},
+			}
+
+			adaptor = New(handler, WithRecorder(recorder))
+		})
+
+		It("does not implement resource.RepositoryAware", func() {
+			_, ok := adaptor.(resource.RepositoryAware)
+			Expect(ok).To(BeFalse())
+		})
+
+		Describe("func Configure()", func() {
+			It("forwards to the handler", func() {
+				Expect(identity.Key(adaptor)).To(Equal("<key>"))
+			})
+		})
+
+		Describe("func HandleEvent()", func() {
+			It("records a handled event", func() {
+				handler.HandleEventFunc = func(
+					context.Context,
+					[]byte, []byte, []byte,
+					dogma.ProjectionEventScope,
+					dogma.Event,
+				) (bool, error) {
+					return true, nil
+				}
+
+				ok, err := adaptor.HandleEvent(ctx, nil, nil, nil, nil, EventA1)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{EventsHandled: 1}))
+				Expect(recorder.histograms).To(HaveKey(HandleEventDuration))
+			})
+
+			It("records an OCC conflict", func() {
+				handler.HandleEventFunc = func(
+					context.Context,
+					[]byte, []byte, []byte,
+					dogma.ProjectionEventScope,
+					dogma.Event,
+				) (bool, error) {
+					return false, nil
+				}
+
+				ok, err := adaptor.HandleEvent(ctx, nil, nil, nil, nil, EventA1)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{OCCConflicts: 1}))
+				Expect(recorder.histograms).To(HaveKey(HandleEventDuration))
+			})
+
+			It("records an error", func() {
+				handler.HandleEventFunc = func(
+					context.Context,
+					[]byte, []byte, []byte,
+					dogma.ProjectionEventScope,
+					dogma.Event,
+				) (bool, error) {
+					return false, errors.New("<error>")
+				}
+
+				_, err := adaptor.HandleEvent(ctx, nil, nil, nil, nil, EventA1)
+				Expect(err).To(MatchError("<error>"))
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{Errors: 1}))
+				Expect(recorder.histograms).To(HaveKey(HandleEventDuration))
+			})
+		})
+
+		Describe("func ResourceVersion()", func() {
+			It("records an error", func() {
+				handler.ResourceVersionFunc = func(context.Context, []byte) ([]byte, error) {
+					return nil, errors.New("<error>")
+				}
+
+				_, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+				Expect(err).To(MatchError("<error>"))
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{Errors: 1}))
+			})
+		})
+
+		Describe("func CloseResource()", func() {
+			It("records an error", func() {
+				handler.CloseResourceFunc = func(context.Context, []byte) error {
+					return errors.New("<error>")
+				}
+
+				err := adaptor.CloseResource(ctx, []byte("<resource>"))
+				Expect(err).To(MatchError("<error>"))
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{Errors: 1}))
+			})
+		})
+
+		Describe("func Compact()", func() {
+			It("records the duration", func() {
+				err := adaptor.Compact(ctx, nil)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(recorder.counters).To(BeEmpty())
+				Expect(recorder.histograms).To(HaveKey(CompactDuration))
+			})
+
+			It("records an error", func() {
+				handler.CompactFunc = func(context.Context, dogma.ProjectionCompactScope) error {
+					return errors.New("<error>")
+				}
+
+				err := adaptor.Compact(ctx, nil)
+				Expect(err).To(MatchError("<error>"))
+
+				Expect(recorder.counters).To(Equal(map[Counter]int{Errors: 1}))
+				Expect(recorder.histograms).To(HaveKey(CompactDuration))
+			})
+		})
+	})
+})
+
+// batchHandlerStub is a test implementation of sqlprojection.BatchHandler.
+type batchHandlerStub struct {
+	*ProjectionMessageHandlerStub
+
+	HandleEventBatchFunc func(
+		ctx context.Context,
+		r, c, n []byte,
+		events []sqlprojection.BatchEvent,
+	) (bool, error)
+}
+
+func (h *batchHandlerStub) HandleEventBatch(
+	ctx context.Context,
+	r, c, n []byte,
+	events []sqlprojection.BatchEvent,
+) (bool, error) {
+	if h.HandleEventBatchFunc != nil {
+		return h.HandleEventBatchFunc(ctx, r, c, n, events)
+	}
+	return true, nil
+}
+
+// repositoryAwareBatchHandlerStub is a batchHandlerStub that also implements
+// resource.RepositoryAware.
+type repositoryAwareBatchHandlerStub struct {
+	*batchHandlerStub
+}
+
+func (repositoryAwareBatchHandlerStub) ResourceRepository(context.Context) (resource.Repository, error) {
+	return nil, nil
+}
+
+// recorderStub is a Recorder that records metrics for the "<key>" handler in
+// memory.
+type recorderStub struct {
+	m          sync.Mutex
+	counters   map[Counter]int
+	histograms map[Histogram][]time.Duration
+}
+
+func (r *recorderStub) IncrementCounter(k string, c Counter) {
+	Expect(k).To(Equal("<key>"))
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.counters == nil {
+		r.counters = map[Counter]int{}
+	}
+	r.counters[c]++
+}
+
+func (r *recorderStub) ObserveDuration(k string, h Histogram, d time.Duration) {
+	Expect(k).To(Equal("<key>"))
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.histograms == nil {
+		r.histograms = map[Histogram][]time.Duration{}
+	}
+	r.histograms[h] = append(r.histograms[h], d)
+}
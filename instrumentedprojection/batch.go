@@ -0,0 +1,45 @@
+package instrumentedprojection
+
+import (
+	"context"
+	"time"
+
+	"github.com/dogmatiq/projectionkit/resource"
+	"github.com/dogmatiq/projectionkit/sqlprojection"
+)
+
+// batchAdaptor is an adaptor that wraps a handler that implements
+// sqlprojection.BatchHandler.
+type batchAdaptor struct {
+	*adaptor
+	batch sqlprojection.BatchHandler
+}
+
+// repositoryAwareBatchAdaptor is an adaptor that wraps a handler that
+// implements both sqlprojection.BatchHandler and resource.RepositoryAware.
+type repositoryAwareBatchAdaptor struct {
+	batchAdaptor
+	repo resource.RepositoryAware
+}
+
+// HandleEventBatch updates the projection to reflect the occurrence of each of
+// the given events, in order.
+//
+// A single duration is recorded for the batch. If the batch is applied, each
+// of its events is counted as handled.
+func (a batchAdaptor) HandleEventBatch(
+	ctx context.Context,
+	r, c, n []byte,
+	events []sqlprojection.BatchEvent,
+) (bool, error) {
+	start := time.Now()
+	ok, err := a.batch.HandleEventBatch(ctx, r, c, n, events)
+	a.recordHandleEvent(start, len(events), ok, err)
+	return ok, err
+}
+
+// ResourceRepository returns a repository that can be used to manipulate the
+// handler's resource versions.
+func (a repositoryAwareBatchAdaptor) ResourceRepository(ctx context.Context) (resource.Repository, error) {
+	return a.repo.ResourceRepository(ctx)
+}
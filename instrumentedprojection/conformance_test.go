@@ -0,0 +1,28 @@
+package instrumentedprojection_test
+
+import (
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/conformance"
+	. "github.com/dogmatiq/projectionkit/instrumentedprojection"
+	"github.com/dogmatiq/projectionkit/memoryprojection"
+	"github.com/dogmatiq/projectionkit/memoryprojection/fixtures"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.RunAdaptorTests(
+		t,
+		func(*testing.T) dogma.ProjectionMessageHandler {
+			return New(
+				&memoryprojection.Projection[int, *fixtures.MessageHandler[int]]{
+					Handler: &fixtures.MessageHandler[int]{
+						ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+							c.Identity("<projection>", "<key>")
+						},
+					},
+				},
+			)
+		},
+	)
+}
@@ -0,0 +1,3 @@
+// Package instrumentedprojection provides a Dogma projection message handler
+// that records metrics about another handler.
+package instrumentedprojection
@@ -0,0 +1,159 @@
+package instrumentedprojection
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// DefaultRecorder returns the Recorder used when no WithRecorder() option is
+// provided.
+//
+// It is an ExpvarRecorder that publishes its metrics under the "projectionkit"
+// expvar variable. The variable is published the first time DefaultRecorder()
+// is called.
+func DefaultRecorder() *ExpvarRecorder {
+	return defaultRecorder()
+}
+
+var defaultRecorder = sync.OnceValue(
+	func() *ExpvarRecorder {
+		return NewExpvarRecorder("projectionkit")
+	},
+)
+
+// ExpvarRecorder is a Recorder that publishes metrics using the expvar package.
+//
+// The published variable is a map keyed by handler identity key. Each entry is
+// itself a map of counter and histogram names to their current values.
+// Histograms are rendered as a JSON object containing the number of
+// observations, their sum in seconds, and the cumulative number of
+// observations that fall within each bucket.
+type ExpvarRecorder struct {
+	m        sync.Mutex
+	handlers expvar.Map
+}
+
+// NewExpvarRecorder returns a new ExpvarRecorder that publishes its metrics
+// as an expvar variable with the given name.
+//
+// It panics if a variable with that name has already been published.
+func NewExpvarRecorder(name string) *ExpvarRecorder {
+	r := &ExpvarRecorder{}
+	expvar.Publish(name, &r.handlers)
+	return r
+}
+
+// IncrementCounter adds one to the counter c for the handler with the
+// identity key k.
+func (r *ExpvarRecorder) IncrementCounter(k string, c Counter) {
+	r.handler(k).Add(string(c), 1)
+}
+
+// ObserveDuration adds d to the histogram h for the handler with the identity
+// key k.
+func (r *ExpvarRecorder) ObserveDuration(k string, h Histogram, d time.Duration) {
+	m := r.handler(k)
+
+	hist, ok := m.Get(string(h)).(*histogram)
+	if !ok {
+		r.m.Lock()
+		hist, ok = m.Get(string(h)).(*histogram)
+		if !ok {
+			hist = &histogram{}
+			m.Set(string(h), hist)
+		}
+		r.m.Unlock()
+	}
+
+	hist.observe(d)
+}
+
+// handler returns the map containing the metrics for the handler with the
+// identity key k, creating it if necessary.
+func (r *ExpvarRecorder) handler(k string) *expvar.Map {
+	if m, ok := r.handlers.Get(k).(*expvar.Map); ok {
+		return m
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if m, ok := r.handlers.Get(k).(*expvar.Map); ok {
+		return m
+	}
+
+	m := &expvar.Map{}
+	r.handlers.Set(k, m)
+
+	return m
+}
+
+// histogramBuckets are the upper bounds of the buckets used by each histogram.
+var histogramBuckets = [...]time.Duration{
+	1 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// histogram is an expvar.Var that records a distribution of durations.
+type histogram struct {
+	m       sync.Mutex
+	count   uint64
+	sum     time.Duration
+	buckets [len(histogramBuckets)]uint64
+}
+
+// observe adds d to the histogram.
+func (h *histogram) observe(d time.Duration) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.count++
+	h.sum += d
+
+	for i, le := range histogramBuckets {
+		if d <= le {
+			h.buckets[i]++
+		}
+	}
+}
+
+// String returns a JSON representation of the histogram.
+func (h *histogram) String() string {
+	type bucket struct {
+		LE    float64 `json:"le"`
+		Count uint64  `json:"count"`
+	}
+
+	var v struct {
+		Count   uint64   `json:"count"`
+		Sum     float64  `json:"sum"`
+		Buckets []bucket `json:"buckets"`
+	}
+
+	h.m.Lock()
+	v.Count = h.count
+	v.Sum = h.sum.Seconds()
+	for i, le := range histogramBuckets {
+		v.Buckets = append(v.Buckets, bucket{le.Seconds(), h.buckets[i]})
+	}
+	h.m.Unlock()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,61 @@
+package instrumentedprojection_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"time"
+
+	. "github.com/dogmatiq/projectionkit/instrumentedprojection"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type ExpvarRecorder", func() {
+	It("publishes counters and histograms for each handler", func() {
+		r := NewExpvarRecorder("projectionkit-test")
+
+		r.IncrementCounter("<key>", EventsHandled)
+		r.IncrementCounter("<key>", EventsHandled)
+		r.IncrementCounter("<other>", OCCConflicts)
+		r.ObserveDuration("<key>", CompactDuration, 3*time.Millisecond)
+		r.ObserveDuration("<key>", CompactDuration, 2*time.Second)
+
+		var v struct {
+			Key struct {
+				EventsHandled   int `json:"events_handled"`
+				CompactDuration struct {
+					Count   int     `json:"count"`
+					Sum     float64 `json:"sum"`
+					Buckets []struct {
+						LE    float64 `json:"le"`
+						Count int     `json:"count"`
+					} `json:"buckets"`
+				} `json:"compact_duration"`
+			} `json:"<key>"`
+			Other map[string]int `json:"<other>"`
+		}
+
+		err := json.Unmarshal([]byte(expvar.Get("projectionkit-test").String()), &v)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(v.Key.EventsHandled).To(Equal(2))
+		Expect(v.Other).To(Equal(map[string]int{"occ_conflicts": 1}))
+
+		h := v.Key.CompactDuration
+		Expect(h.Count).To(Equal(2))
+		Expect(h.Sum).To(BeNumerically("~", 2.003))
+		Expect(h.Buckets[0].LE).To(Equal(0.001))
+		Expect(h.Buckets[0].Count).To(Equal(0))
+		Expect(h.Buckets[1].LE).To(Equal(0.005))
+		Expect(h.Buckets[1].Count).To(Equal(1))
+		Expect(h.Buckets[len(h.Buckets)-1].Count).To(Equal(2))
+	})
+})
+
+var _ = Describe("func DefaultRecorder()", func() {
+	It("publishes the projectionkit variable", func() {
+		r := DefaultRecorder()
+		Expect(DefaultRecorder()).To(BeIdenticalTo(r))
+		Expect(expvar.Get("projectionkit")).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,18 @@
+package instrumentedprojection
+
+// An Option configures the optional behavior of an instrumented projection.
+type Option struct {
+	applyToAdaptor func(*adaptor)
+}
+
+// WithRecorder returns an Option that sets the Recorder used to record metrics.
+//
+// If this option is not provided, metrics are recorded using
+// DefaultRecorder().
+func WithRecorder(r Recorder) Option {
+	return Option{
+		applyToAdaptor: func(a *adaptor) {
+			a.recorder = r
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package instrumentedprojection
+
+import "time"
+
+// Recorder is an interface for recording metrics about projection message
+// handlers.
+//
+// Implementations must be safe for concurrent use.
+type Recorder interface {
+	// IncrementCounter adds one to the counter c for the handler with the
+	// identity key k.
+	IncrementCounter(k string, c Counter)
+
+	// ObserveDuration adds d to the histogram h for the handler with the
+	// identity key k.
+	ObserveDuration(k string, h Histogram, d time.Duration)
+}
+
+// Counter is the name of a monotonically increasing count of occurrences.
+type Counter string
+
+const (
+	// EventsHandled is the number of events that were applied to the
+	// projection successfully.
+	EventsHandled Counter = "events_handled"
+
+	// OCCConflicts is the number of events that were rejected because the
+	// resource version supplied to HandleEvent() did not match the current
+	// version.
+	OCCConflicts Counter = "occ_conflicts"
+
+	// Errors is the number of calls to any of the handler's methods that
+	// returned an error.
+	Errors Counter = "errors"
+)
+
+// Histogram is the name of a distribution of durations.
+type Histogram string
+
+const (
+	// HandleEventDuration is the time taken by each call to HandleEvent(), or
+	// to HandleEventBatch() for handlers that implement
+	// sqlprojection.BatchHandler, regardless of its outcome.
+	HandleEventDuration Histogram = "handle_event_duration"
+
+	// CompactDuration is the time taken by each call to Compact(), regardless
+	// of its outcome.
+	CompactDuration Histogram = "compact_duration"
+)
@@ -1,2 +0,0 @@
-// Package adaptortest contains a common test suite for all handler adaptors.
-package adaptortest
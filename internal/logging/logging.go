@@ -0,0 +1,130 @@
+// Package logging provides structured logging of projection operations.
+package logging
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HandleEvent logs the outcome of a call to HandleEvent().
+//
+// ok and err are the values returned by HandleEvent(). If l is nil, nothing is
+// logged.
+func HandleEvent(
+	ctx context.Context,
+	l *slog.Logger,
+	key string,
+	r, c, n []byte,
+	ok bool,
+	err error,
+) {
+	if l == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("handler", key),
+		Bytes("resource", r),
+		Bytes("current_version", c),
+		Bytes("next_version", n),
+	}
+
+	switch {
+	case err != nil:
+		attrs = append(attrs, slog.String("outcome", "error"), slog.Any("error", err))
+		l.LogAttrs(ctx, slog.LevelError, "unable to handle event", attrs...)
+	case ok:
+		attrs = append(attrs, slog.String("outcome", "applied"))
+		l.LogAttrs(ctx, slog.LevelDebug, "handled event", attrs...)
+	default:
+		attrs = append(attrs, slog.String("outcome", "conflict"))
+		l.LogAttrs(ctx, slog.LevelWarn, "event rejected due to resource version mismatch", attrs...)
+	}
+}
+
+// CloseResource logs the outcome of a call to CloseResource().
+//
+// If l is nil, nothing is logged.
+func CloseResource(
+	ctx context.Context,
+	l *slog.Logger,
+	key string,
+	r []byte,
+	err error,
+) {
+	if l == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("handler", key),
+		Bytes("resource", r),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		l.LogAttrs(ctx, slog.LevelError, "unable to close resource", attrs...)
+	} else {
+		l.LogAttrs(ctx, slog.LevelDebug, "closed resource", attrs...)
+	}
+}
+
+// Compact logs the outcome of a call to Compact().
+//
+// If l is nil, nothing is logged.
+func Compact(
+	ctx context.Context,
+	l *slog.Logger,
+	key string,
+	err error,
+) {
+	if l == nil {
+		return
+	}
+
+	if err != nil {
+		l.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to compact projection",
+			slog.String("handler", key),
+			slog.Any("error", err),
+		)
+	} else {
+		l.LogAttrs(
+			ctx,
+			slog.LevelDebug,
+			"compacted projection",
+			slog.String("handler", key),
+		)
+	}
+}
+
+// Bytes returns an attribute for a binary value, such as a resource or
+// version.
+//
+// Values that consist entirely of printable UTF-8 characters are logged as
+// strings, otherwise they are logged as hexadecimal.
+func Bytes(k string, v []byte) slog.Attr {
+	if isPrintable(v) {
+		return slog.String(k, string(v))
+	}
+	return slog.String(k, "0x"+hex.EncodeToString(v))
+}
+
+func isPrintable(v []byte) bool {
+	if !utf8.Valid(v) {
+		return false
+	}
+
+	for _, r := range string(v) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
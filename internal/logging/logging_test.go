@@ -0,0 +1,84 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	. "github.com/dogmatiq/projectionkit/internal/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func HandleEvent()", func() {
+	var (
+		ctx    context.Context
+		buf    *bytes.Buffer
+		logger *slog.Logger
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		buf = &bytes.Buffer{}
+		logger = slog.New(
+			slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		)
+	})
+
+	record := func() map[string]any {
+		var r map[string]any
+		err := json.Unmarshal(buf.Bytes(), &r)
+		ExpectWithOffset(1, err).ShouldNot(HaveOccurred())
+		delete(r, "time")
+		return r
+	}
+
+	It("logs an applied event", func() {
+		HandleEvent(ctx, logger, "<key>", []byte("<resource>"), nil, []byte("<version>"), true, nil)
+
+		Expect(record()).To(Equal(map[string]any{
+			"level":           "DEBUG",
+			"msg":             "handled event",
+			"handler":         "<key>",
+			"resource":        "<resource>",
+			"current_version": "",
+			"next_version":    "<version>",
+			"outcome":         "applied",
+		}))
+	})
+
+	It("logs a rejected event", func() {
+		HandleEvent(ctx, logger, "<key>", []byte("<resource>"), []byte("<current>"), []byte("<next>"), false, nil)
+
+		Expect(record()).To(Equal(map[string]any{
+			"level":           "WARN",
+			"msg":             "event rejected due to resource version mismatch",
+			"handler":         "<key>",
+			"resource":        "<resource>",
+			"current_version": "<current>",
+			"next_version":    "<next>",
+			"outcome":         "conflict",
+		}))
+	})
+
+	It("logs an error", func() {
+		HandleEvent(ctx, logger, "<key>", []byte{0x00, 0xff}, nil, []byte("<next>"), false, errors.New("<error>"))
+
+		Expect(record()).To(Equal(map[string]any{
+			"level":           "ERROR",
+			"msg":             "unable to handle event",
+			"handler":         "<key>",
+			"resource":        "0x00ff",
+			"current_version": "",
+			"next_version":    "<next>",
+			"outcome":         "error",
+			"error":           "<error>",
+		}))
+	})
+
+	It("does nothing if the logger is nil", func() {
+		HandleEvent(ctx, nil, "<key>", nil, nil, nil, true, nil)
+	})
+})
@@ -0,0 +1,35 @@
+// Package pgnotify provides the names and payloads of the PostgreSQL
+// notifications that are sent when resource versions are updated.
+//
+// It is shared by the sqlprojection and pgxprojection packages, which store
+// resource versions in the same table, such that clients waiting for a
+// notification are woken regardless of which package updated the version.
+package pgnotify
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Channel returns the name of the channel on which notifications are sent
+// when the versions in the given table are updated.
+//
+// table is the quoted, schema-qualified name of the table.
+func Channel(table string) string {
+	h := fnv.New64a()
+	h.Write([]byte(table)) // nolint:errcheck
+	return fmt.Sprintf("projectionkit_%016x", h.Sum64())
+}
+
+// Payload returns the payload of the notification that is sent when the
+// version of resource r for the handler h is updated.
+//
+// Distinct resources may produce the same payload, so receipt of a
+// notification only indicates that the version may have changed.
+func Payload(h string, r []byte) string {
+	d := fnv.New64a()
+	d.Write([]byte(h)) // nolint:errcheck
+	d.Write([]byte{0}) // nolint:errcheck
+	d.Write(r)         // nolint:errcheck
+	return fmt.Sprintf("%016x", d.Sum64())
+}
@@ -0,0 +1,29 @@
+package pgnotify_test
+
+import (
+	. "github.com/dogmatiq/projectionkit/internal/pgnotify"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func Channel()", func() {
+	It("returns a valid unquoted identifier", func() {
+		Expect(Channel(`"projection"."occ"`)).To(MatchRegexp(`^projectionkit_[0-9a-f]{16}$`))
+	})
+
+	It("returns a different channel for each table", func() {
+		Expect(Channel(`"projection"."occ"`)).NotTo(Equal(Channel(`"projection"."other"`)))
+	})
+})
+
+var _ = Describe("func Payload()", func() {
+	It("returns a different payload for each handler and resource", func() {
+		Expect(Payload("<key>", []byte("<resource>"))).To(Equal(Payload("<key>", []byte("<resource>"))))
+		Expect(Payload("<key>", []byte("<resource>"))).NotTo(Equal(Payload("<key>", []byte("<other>"))))
+		Expect(Payload("<key>", []byte("<resource>"))).NotTo(Equal(Payload("<other>", []byte("<resource>"))))
+	})
+
+	It("does not confuse the boundary between the handler and resource", func() {
+		Expect(Payload("<key>", []byte("<resource>"))).NotTo(Equal(Payload("<key><", []byte("resource>"))))
+	})
+})
@@ -3,9 +3,14 @@ package memoryprojection
 import (
 	"bytes"
 	"context"
+	"iter"
+	"log/slog"
+	"slices"
 	"sync"
 
 	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/internal/logging"
 	"github.com/dogmatiq/projectionkit/resource"
 )
 
@@ -13,6 +18,13 @@ import (
 type Projection[T any, H MessageHandler[T]] struct {
 	Handler H
 
+	// Logger is the target for log messages about the projection's
+	// operations. If it is nil, no logging is performed.
+	Logger *slog.Logger
+
+	keyOnce sync.Once
+	key     string
+
 	m         sync.RWMutex
 	resources map[string][]byte
 	value     T
@@ -36,9 +48,32 @@ func (p *Projection[T, H]) Configure(c dogma.ProjectionConfigurer) {
 	p.Handler.Configure(c)
 }
 
+// handlerKey returns the handler's identity key, which is used in log
+// messages. The handler is only configured the first time it is called.
+func (p *Projection[T, H]) handlerKey() string {
+	p.keyOnce.Do(func() {
+		p.key = identity.Key(p)
+	})
+	return p.key
+}
+
 // HandleEvent updates the projection to reflect the occurrence of an event.
 func (p *Projection[T, H]) HandleEvent(
-	_ context.Context,
+	ctx context.Context,
+	r, c, n []byte,
+	s dogma.ProjectionEventScope,
+	m dogma.Event,
+) (bool, error) {
+	ok, err := p.handleEvent(r, c, n, s, m)
+
+	if p.Logger != nil {
+		logging.HandleEvent(ctx, p.Logger, p.handlerKey(), r, c, n, ok, err)
+	}
+
+	return ok, err
+}
+
+func (p *Projection[T, H]) handleEvent(
 	r, c, n []byte,
 	s dogma.ProjectionEventScope,
 	m dogma.Event,
@@ -76,11 +111,27 @@ func (p *Projection[T, H]) ResourceVersion(_ context.Context, r []byte) ([]byte,
 // CloseResource informs the projection that the resource r will not be
 // used in any future calls to HandleEvent().
 func (p *Projection[T, H]) CloseResource(ctx context.Context, r []byte) error {
-	return p.DeleteResource(ctx, r)
+	err := p.DeleteResource(ctx, r)
+
+	if p.Logger != nil {
+		logging.CloseResource(ctx, p.Logger, p.handlerKey(), r, err)
+	}
+
+	return err
 }
 
 // Compact reduces the size of the projection's data.
-func (p *Projection[T, H]) Compact(_ context.Context, s dogma.ProjectionCompactScope) error {
+func (p *Projection[T, H]) Compact(ctx context.Context, s dogma.ProjectionCompactScope) error {
+	p.compact(s)
+
+	if p.Logger != nil {
+		logging.Compact(ctx, p.Logger, p.handlerKey(), nil)
+	}
+
+	return nil
+}
+
+func (p *Projection[T, H]) compact(s dogma.ProjectionCompactScope) {
 	p.m.Lock()
 	defer p.m.Unlock()
 
@@ -88,8 +139,6 @@ func (p *Projection[T, H]) Compact(_ context.Context, s dogma.ProjectionCompactS
 		// Only attempt to compact the value if some events have been applied.
 		p.value = p.Handler.Compact(p.value, s)
 	}
-
-	return nil
 }
 
 // ResourceRepository returns a repository that can be used to manipulate the
@@ -98,6 +147,11 @@ func (p *Projection[T, H]) ResourceRepository(context.Context) (resource.Reposit
 	return p, nil
 }
 
+var (
+	_ resource.ListableRepository   = (*Projection[any, MessageHandler[any]])(nil)
+	_ resource.ResettableRepository = (*Projection[any, MessageHandler[any]])(nil)
+)
+
 // StoreResourceVersion sets the version of the resource r to v without
 // checking the current version.
 func (p *Projection[T, H]) StoreResourceVersion(_ context.Context, r, v []byte) error {
@@ -139,3 +193,48 @@ func (p *Projection[T, H]) DeleteResource(_ context.Context, r []byte) error {
 	delete(p.resources, string(r))
 	return nil
 }
+
+// DeleteAllResources removes all information about all resources.
+//
+// The projection's value is also reset to the zero-value of T.
+func (p *Projection[T, H]) DeleteAllResources(context.Context) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	var zero T
+	p.resources = nil
+	p.value = zero
+
+	return nil
+}
+
+// ListResources returns an iterator over the resources in the repository and
+// their current versions.
+//
+// The resources are yielded in lexicographical order. The iterator operates on
+// a snapshot of the resources taken when iteration begins.
+func (p *Projection[T, H]) ListResources(context.Context) iter.Seq2[resource.Item, error] {
+	return func(yield func(resource.Item, error) bool) {
+		p.m.RLock()
+		items := make([]resource.Item, 0, len(p.resources))
+		for r, v := range p.resources {
+			if len(v) != 0 {
+				items = append(items, resource.Item{
+					Resource: []byte(r),
+					Version:  v,
+				})
+			}
+		}
+		p.m.RUnlock()
+
+		slices.SortFunc(items, func(a, b resource.Item) int {
+			return bytes.Compare(a.Resource, b.Resource)
+		})
+
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
@@ -1,15 +1,18 @@
 package memoryprojection_test
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"log/slog"
 
 	"github.com/dogmatiq/dogma"
 	. "github.com/dogmatiq/enginekit/enginetest/stubs"
-	"github.com/dogmatiq/projectionkit/internal/adaptortest"
 	"github.com/dogmatiq/projectionkit/internal/identity"
 	"github.com/dogmatiq/projectionkit/memoryprojection"
 	. "github.com/dogmatiq/projectionkit/memoryprojection"
 	"github.com/dogmatiq/projectionkit/memoryprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/projectionkit/resource"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -34,8 +37,6 @@ var _ = Describe("type Projection", func() {
 		}
 	})
 
-	adaptortest.DescribeAdaptor(&ctx, &projection)
-
 	Describe("func Configure()", func() {
 		It("forwards to the handler", func() {
 			Expect(identity.Key(projection)).To(Equal("<key>"))
@@ -172,6 +173,21 @@ var _ = Describe("type Projection", func() {
 			})
 		})
 
+		Describe("func ResetHandler()", func() {
+			It("resets the value to the zero-value", func() {
+				err := resource.ResetHandler(ctx, projection)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				r := memoryprojection.Query(
+					projection,
+					func(v int) int {
+						return v
+					},
+				)
+				Expect(r).To(Equal(0))
+			})
+		})
+
 		Describe("func Query()", func() {
 			It("calls the query function with the existing value", func() {
 				r := memoryprojection.Query(
@@ -184,4 +200,72 @@ var _ = Describe("type Projection", func() {
 			})
 		})
 	})
+
+	When("a logger is configured", func() {
+		It("logs the projection's operations", func() {
+			var buf bytes.Buffer
+			projection.Logger = slog.New(
+				slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			)
+
+			ok, err := projection.HandleEvent(
+				ctx,
+				[]byte("<resource>"),
+				[]byte("<incorrect>"),
+				[]byte("<version>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+
+			err = projection.Compact(ctx, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring(`level=WARN msg="event rejected due to resource version mismatch" handler=<key> resource=<resource> current_version=<incorrect> next_version=<version> outcome=conflict`))
+			Expect(buf.String()).To(ContainSubstring(`msg="compacted projection" handler=<key>`))
+		})
+
+		It("only configures the handler once", func() {
+			projection.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			count := 0
+			handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+				count++
+				c.Identity("<projection>", "<key>")
+			}
+
+			_, err := projection.HandleEvent(ctx, []byte("<resource>"), nil, []byte("<version>"), nil, EventA1)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = projection.CloseResource(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = projection.Compact(ctx, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(count).To(Equal(1))
+		})
+	})
+
+	When("the handler panics while compacting", func() {
+		It("releases the lock", func() {
+			_, err := projection.HandleEvent(ctx, []byte("<resource>"), nil, []byte("<version>"), nil, EventA1)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			handler.CompactFunc = func(int, dogma.ProjectionCompactScope) int {
+				panic("<panic>")
+			}
+
+			Expect(func() {
+				projection.Compact(ctx, nil)
+			}).To(PanicWith("<panic>"))
+
+			_, err = projection.ResourceVersion(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = projection.StoreResourceVersion(ctx, []byte("<resource>"), []byte("<next>"))
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,116 @@
+package pgxprojection
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/internal/logging"
+	"github.com/dogmatiq/projectionkit/internal/unboundhandler"
+	"github.com/dogmatiq/projectionkit/resource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// adaptor adapts a pgxprojection.ProjectionMessageHandler to the
+// dogma.ProjectionMessageHandler interface.
+type adaptor struct {
+	db      *pgxpool.Pool
+	handler MessageHandler
+	key     string
+	repo    *ResourceRepository
+	logger  *slog.Logger
+}
+
+// New returns a new Dogma projection message handler by binding a
+// pgx-specific projection handler to a PostgreSQL connection pool.
+//
+// If db is nil the returned handler will return an error whenever an operation
+// that requires the database is performed.
+func New(
+	db *pgxpool.Pool,
+	h MessageHandler,
+	options ...Option,
+) dogma.ProjectionMessageHandler {
+	if db == nil {
+		return unboundhandler.New(h)
+	}
+
+	key := identity.Key(h)
+
+	a := &adaptor{
+		db:      db,
+		handler: h,
+		key:     key,
+		repo: NewResourceRepository(
+			db,
+			key,
+			options...,
+		),
+	}
+
+	for _, opt := range options {
+		if opt.applyToAdaptor != nil {
+			opt.applyToAdaptor(a)
+		}
+	}
+
+	if h, ok := h.(ResettableMessageHandler); ok {
+		a.repo.reset = h.Reset
+	}
+
+	return a
+}
+
+// Configure produces a configuration for this handler by calling methods on
+// the configurer c.
+func (a *adaptor) Configure(c dogma.ProjectionConfigurer) {
+	a.handler.Configure(c)
+}
+
+// HandleEvent updates the projection to reflect the occurrence of an event.
+func (a *adaptor) HandleEvent(
+	ctx context.Context,
+	r, c, n []byte,
+	s dogma.ProjectionEventScope,
+	m dogma.Event,
+) (bool, error) {
+	ok, err := a.repo.UpdateResourceVersionFn(
+		ctx,
+		r, c, n,
+		func(ctx context.Context, tx pgx.Tx) error {
+			return a.handler.HandleEvent(ctx, tx, s, m)
+		},
+	)
+
+	logging.HandleEvent(ctx, a.logger, a.key, r, c, n, ok, err)
+
+	return ok, err
+}
+
+// ResourceVersion returns the version of the resource r.
+func (a *adaptor) ResourceVersion(ctx context.Context, r []byte) ([]byte, error) {
+	return a.repo.ResourceVersion(ctx, r)
+}
+
+// CloseResource informs the projection that the resource r will not be
+// used in any future calls to HandleEvent().
+func (a *adaptor) CloseResource(ctx context.Context, r []byte) error {
+	err := a.repo.DeleteResource(ctx, r)
+	logging.CloseResource(ctx, a.logger, a.key, r, err)
+	return err
+}
+
+// Compact reduces the size of the projection's data.
+func (a *adaptor) Compact(ctx context.Context, s dogma.ProjectionCompactScope) error {
+	err := a.handler.Compact(ctx, a.db, s)
+	logging.Compact(ctx, a.logger, a.key, err)
+	return err
+}
+
+// ResourceRepository returns a repository that can be used to manipulate the
+// handler's resource versions.
+func (a *adaptor) ResourceRepository(context.Context) (resource.Repository, error) {
+	return a.repo, nil
+}
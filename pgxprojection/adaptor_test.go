@@ -0,0 +1,345 @@
+package pgxprojection_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/internal/pgnotify"
+	. "github.com/dogmatiq/projectionkit/pgxprojection"
+	"github.com/dogmatiq/projectionkit/pgxprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/projectionkit/resource"
+	"github.com/dogmatiq/projectionkit/sqlprojection"
+	sqlfixtures "github.com/dogmatiq/projectionkit/sqlprojection/fixtures"
+	"github.com/dogmatiq/sqltest"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type adaptor", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		database *sqltest.Database
+		db       *pgxpool.Pool
+		handler  *fixtures.MessageHandler
+		adaptor  dogma.ProjectionMessageHandler
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+
+		var err error
+		database, err = sqltest.NewDatabase(ctx, sqltest.PGXDriver, sqltest.PostgreSQL)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		db, err = pgxpool.New(ctx, database.DataSource.DSN())
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = CreateSchema(ctx, db)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		handler = &fixtures.MessageHandler{}
+		handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+			c.Identity("<projection>", "<key>")
+		}
+
+		adaptor = New(db, handler)
+	})
+
+	AfterEach(func() {
+		if db != nil {
+			err := DropSchema(ctx, db)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			db.Close()
+		}
+
+		if database != nil {
+			err := database.Close()
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		cancel()
+	})
+
+	Describe("func New()", func() {
+		It("returns an unbound handler if the database is nil", func() {
+			adaptor = New(nil, handler)
+
+			err := adaptor.Compact(
+				context.Background(),
+				nil, // scope
+			)
+			Expect(err).To(MatchError("projection handler has not been bound to a database"))
+		})
+	})
+
+	Describe("func Configure()", func() {
+		It("forwards to the handler", func() {
+			Expect(identity.Key(adaptor)).To(Equal("<key>"))
+		})
+	})
+
+	Describe("func HandleEvent()", func() {
+		It("returns an error if the application's message handler fails", func() {
+			terr := errors.New("handle event test error")
+
+			handler.HandleEventFunc = func(
+				context.Context,
+				pgx.Tx,
+				dogma.ProjectionEventScope,
+				dogma.Event,
+			) error {
+				return terr
+			}
+
+			_, err := adaptor.HandleEvent(
+				context.Background(),
+				[]byte("<resource>"),
+				nil,
+				[]byte("<version 01>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("shares resource versions with the sqlprojection package", func() {
+			ok, err := adaptor.HandleEvent(
+				ctx,
+				[]byte("<resource>"),
+				nil,
+				[]byte("<version 01>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			sqlDB, err := sql.Open("pgx", database.DataSource.DSN())
+			Expect(err).ShouldNot(HaveOccurred())
+			defer sqlDB.Close()
+
+			repo := sqlprojection.NewResourceRepository(sqlDB, "<key>")
+
+			v, err := repo.ResourceVersion(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(Equal([]byte("<version 01>")))
+
+			ok, err = repo.UpdateResourceVersion(
+				ctx,
+				[]byte("<resource>"),
+				[]byte("<version 01>"),
+				[]byte("<version 02>"),
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			v, err = adaptor.ResourceVersion(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(Equal([]byte("<version 02>")))
+		})
+	})
+
+	Describe("func ResetHandler()", func() {
+		It("calls the handler's Reset() method", func() {
+			handler.ResetFunc = func(context.Context, pgx.Tx) error {
+				return errors.New("<error>")
+			}
+
+			err := resource.ResetHandler(ctx, adaptor)
+			Expect(err).To(MatchError("<error>"))
+		})
+	})
+
+	Describe("func Compact()", func() {
+		It("forwards to the handler", func() {
+			handler.CompactFunc = func(
+				_ context.Context,
+				d *pgxpool.Pool,
+				_ dogma.ProjectionCompactScope,
+			) error {
+				Expect(d).To(BeIdenticalTo(db))
+				return errors.New("<error>")
+			}
+
+			err := adaptor.Compact(
+				context.Background(),
+				nil, // scope
+			)
+			Expect(err).To(MatchError("<error>"))
+		})
+	})
+
+	Describe("func WithNotifications()", func() {
+		It("notifies clients when a resource version is updated", func() {
+			conn, err := db.Acquire(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer conn.Release()
+
+			channel := pgnotify.Channel(`"projection"."occ"`)
+
+			_, err = conn.Exec(ctx, `LISTEN `+pgx.Identifier{channel}.Sanitize())
+			Expect(err).ShouldNot(HaveOccurred())
+
+			adaptor := New(db, handler, WithNotifications(true))
+
+			ok, err := adaptor.HandleEvent(
+				ctx,
+				[]byte("<resource>"),
+				nil,
+				[]byte("<version>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			waitCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			defer cancel()
+
+			n, err := conn.Conn().WaitForNotification(waitCtx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n.Payload).To(Equal(pgnotify.Payload("<key>", []byte("<resource>"))))
+		})
+
+		It("does not notify clients by default", func() {
+			conn, err := db.Acquire(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer conn.Release()
+
+			channel := pgnotify.Channel(`"projection"."occ"`)
+
+			_, err = conn.Exec(ctx, `LISTEN `+pgx.Identifier{channel}.Sanitize())
+			Expect(err).ShouldNot(HaveOccurred())
+
+			ok, err := adaptor.HandleEvent(
+				ctx,
+				[]byte("<resource>"),
+				nil,
+				[]byte("<version>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+
+			_, err = conn.Conn().WaitForNotification(waitCtx)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+	})
+
+	Describe("func WithTableName()", func() {
+		It("shares resource versions with the sqlprojection package", func() {
+			opt := WithTableName("", `custom "occ" table`)
+
+			err := CreateSchema(ctx, db, opt)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer DropSchema(ctx, db, opt)
+
+			adaptor := New(db, handler, opt)
+
+			ok, err := adaptor.HandleEvent(
+				ctx,
+				[]byte("<resource>"),
+				nil,
+				[]byte("<version>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			sqlDB, err := sql.Open("pgx", database.DataSource.DSN())
+			Expect(err).ShouldNot(HaveOccurred())
+			defer sqlDB.Close()
+
+			v, err := sqlprojection.NewResourceRepository(
+				sqlDB,
+				"<key>",
+				sqlprojection.WithTableName("", `custom "occ" table`),
+			).ResourceVersion(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(Equal([]byte("<version>")))
+
+			v, err = NewResourceRepository(db, "<key>").ResourceVersion(ctx, []byte("<resource>"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v).To(BeEmpty())
+		})
+	})
+
+	Describe("func RenameHandlerKey()", func() {
+		It("moves the handler's migration versions", func() {
+			sqlDB, err := sql.Open("pgx", database.DataSource.DSN())
+			Expect(err).ShouldNot(HaveOccurred())
+			defer sqlDB.Close()
+
+			var applied int
+			h := &sqlfixtures.MigratingMessageHandler{}
+			h.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+				c.Identity("<projection>", "<key>")
+			}
+			h.MigrationsFunc = func(sqlprojection.Dialect) []sqlprojection.Migration {
+				return []sqlprojection.Migration{
+					{
+						Version: 1,
+						Apply: func(context.Context, *sql.Tx) error {
+							applied++
+							return nil
+						},
+					},
+				}
+			}
+
+			err = sqlprojection.MigrateHandler(ctx, sqlDB, h)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = NewResourceRepository(db, "<key>").RenameHandlerKey(ctx, "<key>", "<renamed>")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			h.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+				c.Identity("<projection>", "<renamed>")
+			}
+
+			err = sqlprojection.MigrateHandler(ctx, sqlDB, h)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(applied).To(Equal(1))
+		})
+	})
+
+	Describe("func WithLogger()", func() {
+		It("logs the handler's operations", func() {
+			var buf bytes.Buffer
+			logger := slog.New(
+				slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			)
+
+			adaptor := New(db, handler, WithLogger(logger))
+
+			ok, err := adaptor.HandleEvent(
+				ctx,
+				[]byte("<resource>"),
+				nil,
+				[]byte("<version>"),
+				nil,
+				EventA1,
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			Expect(buf.String()).To(ContainSubstring(`msg="handled event" handler=<key> resource=<resource> current_version="" next_version=<version> outcome=applied`))
+		})
+	})
+})
@@ -0,0 +1,48 @@
+package pgxprojection_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/conformance"
+	. "github.com/dogmatiq/projectionkit/pgxprojection"
+	"github.com/dogmatiq/projectionkit/pgxprojection/fixtures"
+	"github.com/dogmatiq/sqltest"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.RunAdaptorTests(
+		t,
+		func(t *testing.T) dogma.ProjectionMessageHandler {
+			ctx := context.Background()
+
+			database, err := sqltest.NewDatabase(ctx, sqltest.PGXDriver, sqltest.PostgreSQL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() {
+				database.Close()
+			})
+
+			db, err := pgxpool.New(ctx, database.DataSource.DSN())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(db.Close)
+
+			if err := CreateSchema(ctx, db); err != nil {
+				t.Fatal(err)
+			}
+
+			return New(db, &fixtures.MessageHandler{
+				ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+					c.Identity("<projection>", "<key>")
+				},
+			})
+		},
+	)
+}
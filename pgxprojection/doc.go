@@ -0,0 +1,8 @@
+// Package pgxprojection provides utilities for building PostgreSQL-based
+// projections using the pgx driver directly, without database/sql.
+//
+// Resource versions are stored in the same table, and with the same
+// optimistic concurrency control semantics, as the PostgreSQL driver in the
+// sqlprojection package, so the two packages can be used interchangeably with
+// the same database.
+package pgxprojection
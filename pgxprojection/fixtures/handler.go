@@ -0,0 +1,71 @@
+package fixtures
+
+import (
+	"context"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MessageHandler is a test implementation of pgxprojection.MessageHandler.
+type MessageHandler struct {
+	ConfigureFunc   func(dogma.ProjectionConfigurer)
+	HandleEventFunc func(context.Context, pgx.Tx, dogma.ProjectionEventScope, dogma.Event) error
+	CompactFunc     func(context.Context, *pgxpool.Pool, dogma.ProjectionCompactScope) error
+	ResetFunc       func(context.Context, pgx.Tx) error
+}
+
+// Configure configures the behavior of the engine as it relates to this
+// handler.
+//
+// c provides access to the various configuration options, such as specifying
+// which types of event messages are routed to this handler.
+//
+// If h.ConfigureFunc is non-nil, it calls h.ConfigureFunc(c).
+func (h *MessageHandler) Configure(c dogma.ProjectionConfigurer) {
+	if h.ConfigureFunc != nil {
+		h.ConfigureFunc(c)
+	}
+}
+
+// HandleEvent handles a domain event message that has been routed to this
+// handler.
+//
+// If h.HandleEventFunc is non-nil it returns h.HandleEventFunc(ctx, tx, s, m).
+func (h *MessageHandler) HandleEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	s dogma.ProjectionEventScope,
+	m dogma.Event,
+) error {
+	if h.HandleEventFunc != nil {
+		return h.HandleEventFunc(ctx, tx, s, m)
+	}
+
+	return nil
+}
+
+// Compact reduces the size of the projection's data.
+//
+// If h.CompactFunc is non-nil it returns h.CompactFunc(ctx,db,s), otherwise it
+// returns nil.
+func (h *MessageHandler) Compact(ctx context.Context, db *pgxpool.Pool, s dogma.ProjectionCompactScope) error {
+	if h.CompactFunc != nil {
+		return h.CompactFunc(ctx, db, s)
+	}
+
+	return nil
+}
+
+// Reset removes all of the projection's data.
+//
+// If h.ResetFunc is non-nil it returns h.ResetFunc(ctx, tx), otherwise it
+// returns nil.
+func (h *MessageHandler) Reset(ctx context.Context, tx pgx.Tx) error {
+	if h.ResetFunc != nil {
+		return h.ResetFunc(ctx, tx)
+	}
+
+	return nil
+}
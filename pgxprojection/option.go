@@ -0,0 +1,37 @@
+package pgxprojection
+
+import "log/slog"
+
+// An Option configures the optional behavior of a pgx projection.
+type Option struct {
+	applyToTableName  func(*tableName)
+	applyToRepository func(*ResourceRepository)
+	applyToAdaptor    func(*adaptor)
+}
+
+// WithLogger returns an Option that causes the projection to log its
+// operations to l.
+func WithLogger(l *slog.Logger) Option {
+	return Option{
+		applyToAdaptor: func(a *adaptor) {
+			a.logger = l
+		},
+	}
+}
+
+// WithNotifications returns an Option that determines whether a notification
+// is sent whenever a resource version is updated.
+//
+// The notifications are identical to those sent by the sqlprojection package's
+// PostgreSQL driver when its WithNotifications() option is enabled. They wake
+// clients that are blocked in sqlprojection.WaitForResourceVersion(), which
+// otherwise detect new versions only once they query the version again.
+//
+// Notifications are disabled by default.
+func WithNotifications(enabled bool) Option {
+	return Option{
+		applyToRepository: func(rr *ResourceRepository) {
+			rr.notifications = enabled
+		},
+	}
+}
@@ -0,0 +1,454 @@
+package pgxprojection
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	"github.com/dogmatiq/projectionkit/internal/pgnotify"
+	"github.com/dogmatiq/projectionkit/resource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ResourceRepository is an implementation of resource.Repository that stores
+// resources versions in a PostgreSQL database.
+//
+// It uses the same table as the PostgreSQL driver in the sqlprojection
+// package.
+type ResourceRepository struct {
+	db            *pgxpool.Pool
+	key           string
+	name          tableName
+	reset         func(context.Context, pgx.Tx) error
+	notifications bool
+}
+
+var (
+	_ resource.ListableRepository   = (*ResourceRepository)(nil)
+	_ resource.ResettableRepository = (*ResourceRepository)(nil)
+	_ resource.RenamableRepository  = (*ResourceRepository)(nil)
+)
+
+// NewResourceRepository returns a new pgx resource repository.
+func NewResourceRepository(
+	db *pgxpool.Pool,
+	key string,
+	options ...Option,
+) *ResourceRepository {
+	rr := &ResourceRepository{
+		db:   db,
+		key:  key,
+		name: resolveTableName(options),
+	}
+
+	for _, opt := range options {
+		if opt.applyToRepository != nil {
+			opt.applyToRepository(rr)
+		}
+	}
+
+	return rr
+}
+
+// ResourceVersion returns the version of the resource r.
+func (rr *ResourceRepository) ResourceVersion(ctx context.Context, r []byte) ([]byte, error) {
+	row := rr.db.QueryRow(
+		ctx,
+		`SELECT
+			version
+		FROM `+rr.occ()+`
+		WHERE handler = $1
+		AND resource = $2`,
+		[]byte(rr.key),
+		r,
+	)
+
+	var v []byte
+	err := row.Scan(&v)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	return v, err
+}
+
+// StoreResourceVersion sets the version of the resource r to v without checking
+// the current version.
+func (rr *ResourceRepository) StoreResourceVersion(ctx context.Context, r, v []byte) error {
+	if len(v) == 0 {
+		return rr.DeleteResource(ctx, r)
+	}
+
+	_, err := rr.db.Exec(
+		ctx,
+		`INSERT INTO `+rr.occ()+` (
+			handler,
+			resource,
+			version
+		) VALUES (
+			$1,
+			$2,
+			$3
+		) ON CONFLICT (handler, resource) DO UPDATE SET
+			version = excluded.version`,
+		[]byte(rr.key),
+		r,
+		v,
+	)
+	if err != nil {
+		return err
+	}
+
+	return rr.notify(ctx, rr.db, r)
+}
+
+// UpdateResourceVersion updates the version of the resource r to n.
+//
+// If c is not the current version of r, it returns false and no update occurs.
+func (rr *ResourceRepository) UpdateResourceVersion(
+	ctx context.Context,
+	r, c, n []byte,
+) (ok bool, err error) {
+	return rr.withTx(ctx, func(tx pgx.Tx) (bool, error) {
+		return rr.updateVersion(ctx, tx, r, c, n)
+	})
+}
+
+// UpdateResourceVersionFn updates the version of the resource r to n and
+// performs a user-defined operation within the same transaction.
+//
+// If c is not the current version of r, it returns false and no update occurs.
+func (rr *ResourceRepository) UpdateResourceVersionFn(
+	ctx context.Context,
+	r, c, n []byte,
+	fn func(context.Context, pgx.Tx) error,
+) (ok bool, err error) {
+	return rr.withTx(ctx, func(tx pgx.Tx) (bool, error) {
+		ok, err := rr.updateVersion(ctx, tx, r, c, n)
+		if !ok || err != nil {
+			return false, err
+		}
+
+		return true, fn(ctx, tx)
+	})
+}
+
+// DeleteResource removes all information about the resource r.
+func (rr *ResourceRepository) DeleteResource(ctx context.Context, r []byte) error {
+	_, err := rr.db.Exec(
+		ctx,
+		`DELETE FROM `+rr.occ()+`
+		WHERE handler = $1
+		AND resource = $2`,
+		[]byte(rr.key),
+		r,
+	)
+
+	return err
+}
+
+// DeleteAllResources removes all information about all resources.
+//
+// If the repository belongs to a handler created by New() that implements
+// ResettableMessageHandler, the handler's Reset() method is called within the
+// same transaction.
+func (rr *ResourceRepository) DeleteAllResources(ctx context.Context) error {
+	_, err := rr.withTx(ctx, func(tx pgx.Tx) (bool, error) {
+		if _, err := tx.Exec(
+			ctx,
+			`DELETE FROM `+rr.occ()+`
+			WHERE handler = $1`,
+			[]byte(rr.key),
+		); err != nil {
+			return false, err
+		}
+
+		if rr.reset != nil {
+			if err := rr.reset(ctx, tx); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	})
+
+	return err
+}
+
+// RenameHandlerKey moves the versions of all resources stored for the handler
+// key o such that they are stored for the handler key n instead.
+//
+// The outbox records and handler migration versions that are stored by the
+// sqlprojection package are also moved. Everything is moved within a single
+// transaction.
+func (rr *ResourceRepository) RenameHandlerKey(ctx context.Context, o, n string) error {
+	ok, err := rr.withTx(ctx, func(tx pgx.Tx) (bool, error) {
+		row := tx.QueryRow(
+			ctx,
+			`SELECT
+				COUNT(*)
+			FROM `+rr.occ()+`
+			WHERE handler = $1`,
+			[]byte(n),
+		)
+
+		var count int
+		if err := row.Scan(&count); err != nil {
+			return false, err
+		}
+
+		if count != 0 {
+			return false, nil
+		}
+
+		// The same tables are updated as by the sqlprojection package's
+		// PostgreSQL driver.
+		for _, t := range []string{
+			rr.occ(),
+			rr.table().withSuffix("_outbox").qualified(),
+			rr.table().withSuffix("_handler_version").qualified(),
+		} {
+			if _, err := tx.Exec(
+				ctx,
+				`UPDATE `+t+` SET
+					handler = $1
+				WHERE handler = $2`,
+				[]byte(n),
+				[]byte(o),
+			); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	})
+
+	if err == nil && !ok {
+		return resource.ErrHandlerKeyInUse
+	}
+
+	return err
+}
+
+// ListResources returns an iterator over the resources in the repository and
+// their current versions.
+//
+// Each page of resources is fetched using a separate query.
+func (rr *ResourceRepository) ListResources(ctx context.Context) iter.Seq2[resource.Item, error] {
+	return func(yield func(resource.Item, error) bool) {
+		after := []byte{}
+
+		for {
+			items, err := rr.queryVersions(ctx, after)
+			if err != nil {
+				yield(resource.Item{}, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if len(items) < listPageSize {
+				return
+			}
+
+			after = items[len(items)-1].Resource
+		}
+	}
+}
+
+// listPageSize is the maximum number of resources that are fetched by each
+// query performed by ResourceRepository.ListResources().
+const listPageSize = 100
+
+// queryVersions returns up to listPageSize resources that sort after the
+// resource a, along with their versions.
+func (rr *ResourceRepository) queryVersions(
+	ctx context.Context,
+	a []byte,
+) ([]resource.Item, error) {
+	rows, err := rr.db.Query(
+		ctx,
+		`SELECT
+			resource,
+			version
+		FROM `+rr.occ()+`
+		WHERE handler = $1
+		AND resource > $2
+		ORDER BY resource
+		LIMIT $3`,
+		[]byte(rr.key),
+		a,
+		listPageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []resource.Item
+
+	for rows.Next() {
+		var item resource.Item
+
+		if err := rows.Scan(&item.Resource, &item.Version); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// updateVersion updates the version of the resource r from c to n within tx.
+func (rr *ResourceRepository) updateVersion(
+	ctx context.Context,
+	tx pgx.Tx,
+	r, c, n []byte,
+) (bool, error) {
+	// If the "current" version is empty, we assumed it's correct and that there
+	// is no existing entry for this resource.
+	if len(c) == 0 {
+		tag, err := tx.Exec(
+			ctx,
+			`INSERT INTO `+rr.occ()+` (
+				handler,
+				resource,
+				version
+			) VALUES (
+				$1,
+				$2,
+				$3
+			) ON CONFLICT DO NOTHING`,
+			[]byte(rr.key),
+			r,
+			n,
+		)
+		if err != nil {
+			return false, err
+		}
+
+		// The affected rows will be exactly 1 if the row was inserted.
+		if tag.RowsAffected() != 1 {
+			return false, nil
+		}
+
+		return true, rr.notify(ctx, tx, r)
+	}
+
+	var (
+		tag pgconn.CommandTag
+		err error
+	)
+
+	if len(n) == 0 {
+		// If the "next" version is empty, we can delete the row entirely.
+		tag, err = tx.Exec(
+			ctx,
+			`DELETE FROM `+rr.occ()+`
+			WHERE handler = $1
+			AND resource = $2
+			AND version = $3`,
+			[]byte(rr.key),
+			r,
+			c,
+		)
+	} else {
+		// Otherwise we simply update the existing row.
+		tag, err = tx.Exec(
+			ctx,
+			`UPDATE `+rr.occ()+` SET
+				version = $1
+			WHERE handler = $2
+			AND resource = $3
+			AND version = $4`,
+			n,
+			[]byte(rr.key),
+			r,
+			c,
+		)
+	}
+
+	if err != nil {
+		// CODE COVERAGE: This branch can not be easily covered without somehow
+		// breaking the database connection or the schema in some way.
+		return false, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	return true, rr.notify(ctx, tx, r)
+}
+
+// table returns the name of the table used to store resource versions.
+func (rr *ResourceRepository) table() tableName {
+	if rr.name.Table == "" {
+		return tableName{"projection", "occ"}
+	}
+	return rr.name
+}
+
+// occ returns the quoted name of the table used to store resource versions.
+func (rr *ResourceRepository) occ() string {
+	return rr.table().qualified()
+}
+
+// notify sends a notification that the version of resource r has been
+// updated, if notifications are enabled.
+//
+// When called within a transaction, the notification is only delivered if the
+// transaction is committed.
+func (rr *ResourceRepository) notify(
+	ctx context.Context,
+	db interface {
+		Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
+	},
+	r []byte,
+) error {
+	if !rr.notifications {
+		return nil
+	}
+
+	_, err := db.Exec(
+		ctx,
+		`SELECT pg_notify($1, $2)`,
+		pgnotify.Channel(rr.occ()),
+		pgnotify.Payload(rr.key, r),
+	)
+	return err
+}
+
+// withTx calls fn within a single transaction.
+//
+// The transaction is committed if fn returns true, otherwise it is rolled
+// back.
+func (rr *ResourceRepository) withTx(
+	ctx context.Context,
+	fn func(pgx.Tx) (bool, error),
+) (bool, error) {
+	tx, err := rr.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx) // nolint:errcheck
+
+	ok, err := fn(tx)
+	if err != nil {
+		return false, err
+	}
+
+	if ok {
+		return true, tx.Commit(ctx)
+	}
+
+	return false, tx.Rollback(ctx)
+}
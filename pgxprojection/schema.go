@@ -0,0 +1,65 @@
+package pgxprojection
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// CreateSchema creates the schema elements necessary to store projections on
+// the given database.
+//
+// It does not return an error if the schema already exists. It is equivalent
+// to calling sqlprojection.CreateSchema() with the PostgreSQL driver.
+func CreateSchema(ctx context.Context, db *pgxpool.Pool, options ...Option) error {
+	return withSQLDB(db, func(sqlDB *sql.DB) error {
+		return sqlprojection.CreateSchema(
+			ctx,
+			sqlDB,
+			sqlOptions(options)...,
+		)
+	})
+}
+
+// DropSchema drops the schema elements necessary to store projections on the
+// given database.
+//
+// It does not return an error if the schema does not exist. It is equivalent
+// to calling sqlprojection.DropSchema() with the PostgreSQL driver.
+func DropSchema(ctx context.Context, db *pgxpool.Pool, options ...Option) error {
+	return withSQLDB(db, func(sqlDB *sql.DB) error {
+		return sqlprojection.DropSchema(
+			ctx,
+			sqlDB,
+			sqlOptions(options)...,
+		)
+	})
+}
+
+// withSQLDB calls fn with a *sql.DB that borrows its connections from db.
+//
+// This allows the schema to be managed by the sqlprojection package, ensuring
+// that both packages use an identical schema.
+func withSQLDB(db *pgxpool.Pool, fn func(*sql.DB) error) error {
+	sqlDB := stdlib.OpenDBFromPool(db)
+	defer sqlDB.Close()
+
+	return fn(sqlDB)
+}
+
+// sqlOptions returns the sqlprojection options that are equivalent to the
+// given options, for the purposes of managing the schema.
+func sqlOptions(options []Option) []sqlprojection.Option {
+	opts := []sqlprojection.Option{
+		sqlprojection.WithDriver(sqlprojection.PostgresDriver),
+	}
+
+	if n := resolveTableName(options); n.Table != "" {
+		opts = append(opts, sqlprojection.WithTableName(n.Schema, n.Table))
+	}
+
+	return opts
+}
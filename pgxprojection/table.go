@@ -0,0 +1,71 @@
+package pgxprojection
+
+import "github.com/jackc/pgx/v5"
+
+// tableName is the name of the table used to store resource versions.
+type tableName struct {
+	// Schema is the name of the PostgreSQL schema that contains the table. If
+	// it is empty the table name is not qualified.
+	Schema string
+
+	// Table is the unqualified name of the table.
+	Table string
+}
+
+// WithTableName returns an Option that sets the name of the table used to
+// store resource versions.
+//
+// It is equivalent to the sqlprojection package's WithTableName() option, and
+// the two options MUST specify the same name for the packages to share
+// resource versions. The same option MUST be passed to CreateSchema(),
+// DropSchema() and New().
+//
+// By default, the "occ" table within the "projection" schema is used.
+//
+// It panics if table is empty.
+func WithTableName(schema, table string) Option {
+	if table == "" {
+		panic("pgxprojection.WithTableName() requires a non-empty table name")
+	}
+
+	return Option{
+		applyToTableName: func(n *tableName) {
+			*n = tableName{schema, table}
+		},
+	}
+}
+
+// resolveTableName returns the table name configured by the given options.
+//
+// It returns the zero value if the name is not configured, in which case the
+// default name is used.
+func resolveTableName(options []Option) tableName {
+	var n tableName
+
+	for _, opt := range options {
+		if opt.applyToTableName != nil {
+			opt.applyToTableName(&n)
+		}
+	}
+
+	return n
+}
+
+// qualified returns the quoted, schema-qualified name of the table.
+//
+// It produces the same result as the sqlprojection package's PostgreSQL
+// driver, which is relied upon to derive the notification channel.
+func (n tableName) qualified() string {
+	if n.Schema == "" {
+		return pgx.Identifier{n.Table}.Sanitize()
+	}
+
+	return pgx.Identifier{n.Schema, n.Table}.Sanitize()
+}
+
+// withSuffix returns the name of a table in the same schema as n, with the
+// given suffix appended to the table name.
+func (n tableName) withSuffix(s string) tableName {
+	n.Table += s
+	return n
+}
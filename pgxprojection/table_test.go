@@ -0,0 +1,17 @@
+package pgxprojection_test
+
+import (
+	"testing"
+
+	. "github.com/dogmatiq/projectionkit/pgxprojection"
+)
+
+func TestWithTableName_PanicsIfTableIsEmpty(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+
+	WithTableName("<schema>", "")
+}
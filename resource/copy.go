@@ -0,0 +1,269 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/dogmatiq/dogma"
+)
+
+// ErrConflict indicates that a resource could not be copied because the
+// destination already has a different version of that resource.
+var ErrConflict = errors.New("the destination has a different version of the resource")
+
+// ConflictPolicy determines how a copy operation behaves when the destination
+// already has a different version of a resource.
+type ConflictPolicy int
+
+const (
+	// FailOnConflict causes the copy operation to stop and return an error
+	// that wraps ErrConflict. It is the default policy.
+	FailOnConflict ConflictPolicy = iota
+
+	// SkipOnConflict causes the copy operation to leave the destination's
+	// version of the resource unchanged.
+	SkipOnConflict
+
+	// OverwriteOnConflict causes the copy operation to replace the
+	// destination's version of the resource with the source's version.
+	OverwriteOnConflict
+)
+
+// CopyOutcome describes what happened to a single resource during a copy
+// operation.
+type CopyOutcome int
+
+const (
+	// Copied indicates that the resource did not exist in the destination and
+	// that its version was copied from the source.
+	Copied CopyOutcome = iota
+
+	// Unchanged indicates that the destination already had the same version
+	// of the resource.
+	Unchanged
+
+	// Skipped indicates that the destination had a different version of the
+	// resource and that it was left unchanged.
+	Skipped
+
+	// Overwritten indicates that the destination had a different version of
+	// the resource and that it was replaced with the source's version.
+	Overwritten
+)
+
+// CopyProgress describes the progress of a copy operation after a single
+// resource has been processed.
+type CopyProgress struct {
+	Item
+
+	// Outcome describes what happened to the resource.
+	//
+	// If the operation is a dry-run, it is the outcome that would have
+	// occurred, but the destination has not been modified.
+	Outcome CopyOutcome
+
+	// Count is the number of resources processed so far, including this one.
+	Count int
+}
+
+// A CopyOption configures the optional behavior of a copy operation.
+type CopyOption struct {
+	applyToCopier func(*copier)
+}
+
+// WithDryRun returns a CopyOption that prevents the copy operation from
+// modifying the destination.
+//
+// All other behavior, including conflict detection and progress reporting, is
+// unaffected.
+func WithDryRun() CopyOption {
+	return CopyOption{
+		applyToCopier: func(c *copier) {
+			c.dryRun = true
+		},
+	}
+}
+
+// WithConflictPolicy returns a CopyOption that sets the policy used when the
+// destination already has a different version of a resource.
+func WithConflictPolicy(p ConflictPolicy) CopyOption {
+	return CopyOption{
+		applyToCopier: func(c *copier) {
+			c.policy = p
+		},
+	}
+}
+
+// WithProgress returns a CopyOption that calls fn after each resource is
+// processed.
+func WithProgress(fn func(CopyProgress)) CopyOption {
+	return CopyOption{
+		applyToCopier: func(c *copier) {
+			c.progress = fn
+		},
+	}
+}
+
+// Copy copies the version of every resource in src to dst.
+//
+// Resources that exist in dst but not in src are left unchanged.
+//
+// It returns ErrNotSupported if src does not implement ListableRepository.
+func Copy(
+	ctx context.Context,
+	src, dst Repository,
+	options ...CopyOption,
+) error {
+	s, ok := src.(ListableRepository)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	return newCopier(options).copy(ctx, s.ListResources(ctx), dst)
+}
+
+// Migrate copies the version of every resource persisted by the handler src to
+// the handler dst.
+//
+// It is intended for moving a projection's resource versions between two
+// different storage backends, for example from a BoltDB database to an SQL
+// database. Typically src and dst are adaptors for the same projection handler
+// bound to different databases.
+//
+// It returns ErrNotSupported if either handler does not support low-level
+// manipulation of its resource versions, or if src does not support
+// enumerating its resources.
+func Migrate(
+	ctx context.Context,
+	src, dst dogma.ProjectionMessageHandler,
+	options ...CopyOption,
+) error {
+	s, err := listableRepository(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	d, ok := dst.(RepositoryAware)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	repo, err := d.ResourceRepository(ctx)
+	if err != nil {
+		return err
+	}
+
+	return Copy(ctx, s, repo, options...)
+}
+
+// copier copies resource versions to a repository.
+type copier struct {
+	dryRun   bool
+	policy   ConflictPolicy
+	progress func(CopyProgress)
+}
+
+// newCopier returns a copier configured with the given options.
+func newCopier(options []CopyOption) *copier {
+	c := &copier{}
+
+	for _, opt := range options {
+		opt.applyToCopier(c)
+	}
+
+	return c
+}
+
+// copy copies each of the items yielded by items to dst.
+func (c *copier) copy(
+	ctx context.Context,
+	items iter.Seq2[Item, error],
+	dst Repository,
+) error {
+	count := 0
+
+	for item, err := range items {
+		if err != nil {
+			return err
+		}
+
+		if len(item.Version) == 0 {
+			// An empty version is equivalent to the resource not existing.
+			continue
+		}
+
+		outcome, err := c.copyItem(ctx, item, dst)
+		if err != nil {
+			return err
+		}
+
+		count++
+
+		if c.progress != nil {
+			c.progress(CopyProgress{
+				Item:    item,
+				Outcome: outcome,
+				Count:   count,
+			})
+		}
+	}
+
+	return nil
+}
+
+// copyItem copies a single item to dst.
+func (c *copier) copyItem(
+	ctx context.Context,
+	item Item,
+	dst Repository,
+) (CopyOutcome, error) {
+	v, err := dst.ResourceVersion(ctx, item.Resource)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(v) == 0 {
+		if c.dryRun {
+			return Copied, nil
+		}
+
+		ok, err := dst.UpdateResourceVersion(ctx, item.Resource, nil, item.Version)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			return Copied, nil
+		}
+
+		// The resource was created in dst after we checked its version, so
+		// we re-read it and treat it as a potential conflict.
+		v, err = dst.ResourceVersion(ctx, item.Resource)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if bytes.Equal(v, item.Version) {
+		return Unchanged, nil
+	}
+
+	switch c.policy {
+	case SkipOnConflict:
+		return Skipped, nil
+
+	case OverwriteOnConflict:
+		if !c.dryRun {
+			if err := dst.StoreResourceVersion(ctx, item.Resource, item.Version); err != nil {
+				return 0, err
+			}
+		}
+		return Overwritten, nil
+
+	default:
+		return 0, fmt.Errorf("unable to copy resource %q: %w", item.Resource, ErrConflict)
+	}
+}
@@ -0,0 +1,181 @@
+package resource_test
+
+import (
+	"context"
+
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/projectionkit/memoryprojection"
+	"github.com/dogmatiq/projectionkit/memoryprojection/fixtures"
+	. "github.com/dogmatiq/projectionkit/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func Copy()", func() {
+	var (
+		ctx      context.Context
+		src, dst *memoryprojection.Projection[int, *fixtures.MessageHandler[int]]
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		src = &memoryprojection.Projection[int, *fixtures.MessageHandler[int]]{}
+		dst = &memoryprojection.Projection[int, *fixtures.MessageHandler[int]]{}
+
+		err := src.StoreResourceVersion(ctx, []byte("<resource-a>"), []byte("<version-a>"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = src.StoreResourceVersion(ctx, []byte("<resource-b>"), []byte("<version-b>"))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	expectVersion := func(r, v string) {
+		ver, err := dst.ResourceVersion(ctx, []byte(r))
+		ExpectWithOffset(1, err).ShouldNot(HaveOccurred())
+		ExpectWithOffset(1, string(ver)).To(Equal(v))
+	}
+
+	It("copies each resource version to the destination", func() {
+		err := Copy(ctx, src, dst)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		expectVersion("<resource-a>", "<version-a>")
+		expectVersion("<resource-b>", "<version-b>")
+	})
+
+	It("reports progress", func() {
+		err := dst.StoreResourceVersion(ctx, []byte("<resource-b>"), []byte("<version-b>"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var progress []CopyProgress
+		err = Copy(
+			ctx,
+			src,
+			dst,
+			WithProgress(func(p CopyProgress) {
+				progress = append(progress, p)
+			}),
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(progress).To(Equal([]CopyProgress{
+			{
+				Item:    Item{Resource: []byte("<resource-a>"), Version: []byte("<version-a>")},
+				Outcome: Copied,
+				Count:   1,
+			},
+			{
+				Item:    Item{Resource: []byte("<resource-b>"), Version: []byte("<version-b>")},
+				Outcome: Unchanged,
+				Count:   2,
+			},
+		}))
+	})
+
+	It("does not modify the destination during a dry-run", func() {
+		var outcomes []CopyOutcome
+		err := Copy(
+			ctx,
+			src,
+			dst,
+			WithDryRun(),
+			WithProgress(func(p CopyProgress) {
+				outcomes = append(outcomes, p.Outcome)
+			}),
+		)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(outcomes).To(Equal([]CopyOutcome{Copied, Copied}))
+
+		expectVersion("<resource-a>", "")
+		expectVersion("<resource-b>", "")
+	})
+
+	When("the destination has a different version of a resource", func() {
+		BeforeEach(func() {
+			err := dst.StoreResourceVersion(ctx, []byte("<resource-a>"), []byte("<other>"))
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns an error by default", func() {
+			err := Copy(ctx, src, dst)
+			Expect(err).To(MatchError(ErrConflict))
+
+			expectVersion("<resource-a>", "<other>")
+		})
+
+		It("skips the resource when using SkipOnConflict", func() {
+			err := Copy(ctx, src, dst, WithConflictPolicy(SkipOnConflict))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			expectVersion("<resource-a>", "<other>")
+			expectVersion("<resource-b>", "<version-b>")
+		})
+
+		It("overwrites the resource when using OverwriteOnConflict", func() {
+			err := Copy(ctx, src, dst, WithConflictPolicy(OverwriteOnConflict))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			expectVersion("<resource-a>", "<version-a>")
+			expectVersion("<resource-b>", "<version-b>")
+		})
+
+		It("does not overwrite the resource during a dry-run", func() {
+			var outcomes []CopyOutcome
+			err := Copy(
+				ctx,
+				src,
+				dst,
+				WithDryRun(),
+				WithConflictPolicy(OverwriteOnConflict),
+				WithProgress(func(p CopyProgress) {
+					outcomes = append(outcomes, p.Outcome)
+				}),
+			)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(outcomes).To(Equal([]CopyOutcome{Overwritten, Copied}))
+
+			expectVersion("<resource-a>", "<other>")
+		})
+	})
+
+	It("returns an error if the source does not support listing resources", func() {
+		err := Copy(ctx, basicRepositoryStub{}, dst)
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+})
+
+var _ = Describe("func Migrate()", func() {
+	It("copies each resource version from the source handler", func() {
+		ctx := context.Background()
+		src := &memoryprojection.Projection[int, *fixtures.MessageHandler[int]]{}
+		dst := &memoryprojection.Projection[int, *fixtures.MessageHandler[int]]{}
+
+		err := src.StoreResourceVersion(ctx, []byte("<resource>"), []byte("<version>"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = Migrate(ctx, src, dst)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		ver, err := dst.ResourceVersion(ctx, []byte("<resource>"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ver).To(Equal([]byte("<version>")))
+	})
+
+	It("returns an error if the source handler does not implement RepositoryAware", func() {
+		err := Migrate(
+			context.Background(),
+			&ProjectionMessageHandlerStub{},
+			&repositoryAwareStub{},
+		)
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+
+	It("returns an error if the destination handler does not implement RepositoryAware", func() {
+		err := Migrate(
+			context.Background(),
+			&repositoryAwareStub{},
+			&ProjectionMessageHandlerStub{},
+		)
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+})
@@ -0,0 +1,164 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/internal/identity"
+)
+
+// The export format is a stream of newline-delimited JSON objects.
+//
+// The first object is a header that identifies the format and the handler
+// whose resource versions follow:
+//
+//	{"format":"projectionkit/resource","version":1,"handler":"<handler key>"}
+//
+// Each subsequent object describes a single resource. The resource and version
+// are arbitrary binary values, and are therefore encoded as standard base64
+// strings:
+//
+//	{"resource":"PHJlc291cmNlPg==","version":"PHZlcnNpb24+"}
+//
+// Readers MUST reject streams with an unrecognized format or a greater version
+// number than they support.
+const (
+	// exportFormat is the value of the "format" property in the header.
+	exportFormat = "projectionkit/resource"
+
+	// exportVersion is the version of the export format produced by Export().
+	exportVersion = 1
+)
+
+// exportHeader is the first JSON object in an export stream.
+type exportHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	Handler string `json:"handler"`
+}
+
+// exportItem is a JSON object representing a single resource in an export
+// stream.
+type exportItem struct {
+	Resource []byte `json:"resource"`
+	Version  []byte `json:"version"`
+}
+
+// Export writes the version of every resource persisted by the handler to w.
+//
+// The output is a portable, versioned stream that can be restored to any
+// handler with the same identity key using Import(), regardless of the
+// underlying storage backend.
+//
+// It returns ErrNotSupported if the handler does not support enumerating its
+// resources.
+func Export(
+	ctx context.Context,
+	w io.Writer,
+	h dogma.ProjectionMessageHandler,
+) error {
+	repo, err := listableRepository(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(exportHeader{
+		Format:  exportFormat,
+		Version: exportVersion,
+		Handler: identity.Key(h),
+	}); err != nil {
+		return err
+	}
+
+	for item, err := range repo.ListResources(ctx) {
+		if err != nil {
+			return err
+		}
+
+		if err := enc.Encode(exportItem(item)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Import reads a stream produced by Export() from r and stores each resource
+// version in the handler's repository.
+//
+// It returns an error if the stream was exported from a handler with a
+// different identity key.
+//
+// The options are used in the same manner as with Copy(). By default, Import()
+// stops and returns an error if the handler already has a different version of
+// any of the imported resources.
+//
+// It returns ErrNotSupported if the handler does not support low-level
+// manipulation of its resource versions.
+func Import(
+	ctx context.Context,
+	r io.Reader,
+	h dogma.ProjectionMessageHandler,
+	options ...CopyOption,
+) error {
+	ra, ok := h.(RepositoryAware)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	dec := json.NewDecoder(r)
+
+	var hdr exportHeader
+	if err := dec.Decode(&hdr); err != nil {
+		return fmt.Errorf("unable to read export header: %w", err)
+	}
+
+	if hdr.Format != exportFormat {
+		return fmt.Errorf("unrecognized export format %q", hdr.Format)
+	}
+
+	if hdr.Version < 1 || hdr.Version > exportVersion {
+		return fmt.Errorf("unsupported export format version %d", hdr.Version)
+	}
+
+	if key := identity.Key(h); hdr.Handler != key {
+		return fmt.Errorf(
+			"export contains resources for the %q handler, not %q",
+			hdr.Handler,
+			key,
+		)
+	}
+
+	repo, err := ra.ResourceRepository(ctx)
+	if err != nil {
+		return err
+	}
+
+	items := func(yield func(Item, error) bool) {
+		for {
+			var item exportItem
+
+			err := dec.Decode(&item)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if err != nil {
+				err = fmt.Errorf("unable to read export item: %w", err)
+			}
+
+			if !yield(Item(item), err) || err != nil {
+				return
+			}
+		}
+	}
+
+	return newCopier(options).copy(ctx, items, repo)
+}
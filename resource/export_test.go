@@ -0,0 +1,133 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/projectionkit/memoryprojection"
+	"github.com/dogmatiq/projectionkit/memoryprojection/fixtures"
+	. "github.com/dogmatiq/projectionkit/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func Export() and Import()", func() {
+	var (
+		ctx      context.Context
+		src, dst *memoryprojection.Projection[int, *fixtures.MessageHandler[int]]
+	)
+
+	newProjection := func(key string) *memoryprojection.Projection[int, *fixtures.MessageHandler[int]] {
+		return &memoryprojection.Projection[int, *fixtures.MessageHandler[int]]{
+			Handler: &fixtures.MessageHandler[int]{
+				ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+					c.Identity("<projection>", key)
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		src = newProjection("<key>")
+		dst = newProjection("<key>")
+
+		err := src.StoreResourceVersion(ctx, []byte("<resource-a>"), []byte("<version-a>"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = src.StoreResourceVersion(ctx, []byte{0x00, 0xff}, []byte{0xff, 0x00})
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("writes a header followed by each resource", func() {
+		var buf bytes.Buffer
+		err := Export(ctx, &buf, src)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(buf.String()).To(Equal(
+			`{"format":"projectionkit/resource","version":1,"handler":"<key>"}` + "\n" +
+				`{"resource":"AP8=","version":"/wA="}` + "\n" +
+				`{"resource":"PHJlc291cmNlLWE+","version":"PHZlcnNpb24tYT4="}` + "\n",
+		))
+	})
+
+	It("restores the exported resource versions", func() {
+		var buf bytes.Buffer
+		err := Export(ctx, &buf, src)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = Import(ctx, &buf, dst)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var items []Item
+		for item, err := range dst.ListResources(ctx) {
+			Expect(err).ShouldNot(HaveOccurred())
+			items = append(items, item)
+		}
+
+		Expect(items).To(Equal([]Item{
+			{Resource: []byte{0x00, 0xff}, Version: []byte{0xff, 0x00}},
+			{Resource: []byte("<resource-a>"), Version: []byte("<version-a>")},
+		}))
+	})
+
+	It("applies the copy options when importing", func() {
+		err := dst.StoreResourceVersion(ctx, []byte("<resource-a>"), []byte("<other>"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		err = Export(ctx, &buf, src)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = Import(ctx, &buf, dst, WithConflictPolicy(OverwriteOnConflict))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		ver, err := dst.ResourceVersion(ctx, []byte("<resource-a>"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ver).To(Equal([]byte("<version-a>")))
+	})
+
+	It("returns an error if the export is for a different handler", func() {
+		var buf bytes.Buffer
+		err := Export(ctx, &buf, src)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = Import(ctx, &buf, newProjection("<other-key>"))
+		Expect(err).To(MatchError(`export contains resources for the "<key>" handler, not "<other-key>"`))
+	})
+
+	It("returns an error if the format is not recognized", func() {
+		err := Import(ctx, strings.NewReader(`{"format":"<unknown>","version":1}`), dst)
+		Expect(err).To(MatchError(`unrecognized export format "<unknown>"`))
+	})
+
+	It("returns an error if the format version is not supported", func() {
+		err := Import(ctx, strings.NewReader(`{"format":"projectionkit/resource","version":2}`), dst)
+		Expect(err).To(MatchError(`unsupported export format version 2`))
+	})
+
+	It("returns an error if an item is malformed", func() {
+		err := Import(
+			ctx,
+			strings.NewReader(
+				`{"format":"projectionkit/resource","version":1,"handler":"<key>"}`+"\n"+
+					`{"resource":`,
+			),
+			dst,
+		)
+		Expect(err).To(MatchError(ContainSubstring("unable to read export item")))
+	})
+
+	It("returns an error if the handler does not support listing resources", func() {
+		err := Export(ctx, &bytes.Buffer{}, &ProjectionMessageHandlerStub{})
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+
+	It("returns an error if the handler does not implement RepositoryAware", func() {
+		err := Import(ctx, &bytes.Buffer{}, &ProjectionMessageHandlerStub{})
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+})
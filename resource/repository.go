@@ -2,6 +2,7 @@ package resource
 
 import (
 	"context"
+	"iter"
 )
 
 // RepositoryAware is an interface for projection message handlers that can
@@ -36,3 +37,66 @@ type Repository interface {
 	// DeleteResource removes all information about the resource r.
 	DeleteResource(ctx context.Context, r []byte) error
 }
+
+// Item is a resource and its current version.
+type Item struct {
+	Resource []byte
+	Version  []byte
+}
+
+// ListableRepository is an extension of [Repository] that can enumerate the
+// resources it stores.
+type ListableRepository interface {
+	Repository
+
+	// ListResources returns an iterator over the resources in the repository
+	// and their current versions.
+	//
+	// Resources are fetched from the underlying store in pages, such that the
+	// entire set is never held in memory at once. If an error occurs the
+	// iterator yields the error and stops.
+	ListResources(ctx context.Context) iter.Seq2[Item, error]
+}
+
+// ResettableRepository is an extension of [Repository] that can remove all of
+// the resource versions it stores in a single operation.
+type ResettableRepository interface {
+	Repository
+
+	// DeleteAllResources removes all information about all resources.
+	//
+	// Where the underlying store allows it, the resource versions are removed
+	// atomically.
+	DeleteAllResources(ctx context.Context) error
+}
+
+// RenamableRepository is an extension of [Repository] that can move resource
+// versions from one handler key to another.
+type RenamableRepository interface {
+	Repository
+
+	// RenameHandlerKey moves the versions of all resources stored for the
+	// handler key o such that they are stored for the handler key n instead.
+	//
+	// It operates on the underlying store as a whole, regardless of which
+	// handler the repository belongs to. Where the underlying store allows it,
+	// the resource versions are moved atomically.
+	//
+	// It returns ErrHandlerKeyInUse if there are already resource versions
+	// stored for n.
+	RenameHandlerKey(ctx context.Context, o, n string) error
+}
+
+// CheckableRepository is an extension of [Repository] that can verify that its
+// underlying store is usable.
+type CheckableRepository interface {
+	Repository
+
+	// CheckHealth returns an error if the underlying store can not be reached,
+	// or is not in a state that allows resource versions to be stored, such as
+	// when the tables that hold the versions do not exist.
+	//
+	// It performs a lightweight read, making it suitable for use in readiness
+	// probes.
+	CheckHealth(ctx context.Context) error
+}
@@ -3,6 +3,7 @@ package resource
 import (
 	"context"
 	"errors"
+	"iter"
 
 	"github.com/dogmatiq/dogma"
 )
@@ -11,6 +12,11 @@ import (
 // resource operation.
 var ErrNotSupported = errors.New("the handler does not support this operation")
 
+// ErrHandlerKeyInUse indicates that resource versions could not be moved to a
+// new handler key because there are already resource versions stored for that
+// key.
+var ErrHandlerKeyInUse = errors.New("there are already resource versions stored for the new handler key")
+
 // StoreVersion unconditionally sets the version of the resource r to v.
 //
 // Care should be taken using this function, as it bypasses the
@@ -86,3 +92,130 @@ func DeleteResource(
 
 	return ErrNotSupported
 }
+
+// ResetHandler removes all information about all of the handler's resources.
+//
+// This causes the handler to behave as though it had never handled any events,
+// such that the engine replays all events from the beginning. It is intended to
+// be used when deploying a new version of a projection that needs to rebuild
+// its data.
+//
+// Some handler implementations also clear their own projection data within the
+// same operation. Refer to the documentation of each adaptor for details.
+//
+// It returns ErrNotSupported if the handler does not support resetting its
+// resources.
+func ResetHandler(
+	ctx context.Context,
+	h dogma.ProjectionMessageHandler,
+) error {
+	if h, ok := h.(RepositoryAware); ok {
+		repo, err := h.ResourceRepository(ctx)
+		if err != nil {
+			return err
+		}
+
+		if repo, ok := repo.(ResettableRepository); ok {
+			return repo.DeleteAllResources(ctx)
+		}
+	}
+
+	return ErrNotSupported
+}
+
+// CheckHealth returns an error if the handler's data store can not be reached,
+// or is not in a state that allows the handler to store resource versions.
+//
+// It is intended to be used to implement readiness probes.
+//
+// It returns ErrNotSupported if the handler does not support health checks.
+func CheckHealth(
+	ctx context.Context,
+	h dogma.ProjectionMessageHandler,
+) error {
+	if h, ok := h.(RepositoryAware); ok {
+		repo, err := h.ResourceRepository(ctx)
+		if err != nil {
+			return err
+		}
+
+		if repo, ok := repo.(CheckableRepository); ok {
+			return repo.CheckHealth(ctx)
+		}
+	}
+
+	return ErrNotSupported
+}
+
+// RenameHandlerKey moves the resource versions stored for the handler key
+// oldKey such that they are stored for the handler key newKey instead.
+//
+// It is intended to be used when changing the identity key of a projection
+// handler without losing track of the events it has already handled. It should
+// be called before any handler using the new key is started.
+//
+// repo may be the repository of any handler that uses the same underlying
+// store, such as one obtained from resource.RepositoryAware.
+//
+// It returns ErrHandlerKeyInUse if there are already resource versions stored
+// for newKey. It returns ErrNotSupported if the repository does not support
+// renaming handler keys.
+func RenameHandlerKey(
+	ctx context.Context,
+	repo Repository,
+	oldKey, newKey string,
+) error {
+	if oldKey == newKey {
+		return nil
+	}
+
+	if repo, ok := repo.(RenamableRepository); ok {
+		return repo.RenameHandlerKey(ctx, oldKey, newKey)
+	}
+
+	return ErrNotSupported
+}
+
+// ListResources returns an iterator over the resources that the handler has
+// persisted, along with their current versions.
+//
+// The iterator yields ErrNotSupported if the handler does not support
+// enumerating its resources.
+func ListResources(
+	ctx context.Context,
+	h dogma.ProjectionMessageHandler,
+) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		repo, err := listableRepository(ctx, h)
+		if err != nil {
+			yield(Item{}, err)
+			return
+		}
+
+		for item, err := range repo.ListResources(ctx) {
+			if !yield(item, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// listableRepository returns the handler's repository if it supports
+// enumerating its resources.
+func listableRepository(
+	ctx context.Context,
+	h dogma.ProjectionMessageHandler,
+) (ListableRepository, error) {
+	if h, ok := h.(RepositoryAware); ok {
+		repo, err := h.ResourceRepository(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if repo, ok := repo.(ListableRepository); ok {
+			return repo, nil
+		}
+	}
+
+	return nil, ErrNotSupported
+}
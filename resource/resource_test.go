@@ -3,6 +3,8 @@ package resource_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"iter"
 
 	. "github.com/dogmatiq/enginekit/enginetest/stubs"
 	. "github.com/dogmatiq/projectionkit/resource"
@@ -82,14 +84,163 @@ var _ = Describe("func DeleteResource()", func() {
 	})
 })
 
+var _ = Describe("func ResetHandler()", func() {
+	It("uses the repository if the handler implements RepositoryAware", func() {
+		err := ResetHandler(
+			context.Background(),
+			&repositoryAwareStub{},
+		)
+
+		Expect(err).To(MatchError("<reset error>"))
+	})
+
+	It("returns an error if the repository does not support resetting", func() {
+		err := ResetHandler(
+			context.Background(),
+			&repositoryAwareStub{basic: true},
+		)
+
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+
+	It("returns an error if the handler does not implement RepositoryAware", func() {
+		err := ResetHandler(
+			context.Background(),
+			&ProjectionMessageHandlerStub{},
+		)
+
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+})
+
+var _ = Describe("func CheckHealth()", func() {
+	It("uses the repository if the handler implements RepositoryAware", func() {
+		err := CheckHealth(
+			context.Background(),
+			&repositoryAwareStub{},
+		)
+
+		Expect(err).To(MatchError("<health error>"))
+	})
+
+	It("returns an error if the repository does not support health checks", func() {
+		err := CheckHealth(
+			context.Background(),
+			&repositoryAwareStub{basic: true},
+		)
+
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+
+	It("returns an error if the handler does not implement RepositoryAware", func() {
+		err := CheckHealth(
+			context.Background(),
+			&ProjectionMessageHandlerStub{},
+		)
+
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+})
+
+var _ = Describe("func RenameHandlerKey()", func() {
+	It("calls RenameHandlerKey() on the repository", func() {
+		err := RenameHandlerKey(
+			context.Background(),
+			repositoryStub{},
+			"<old>",
+			"<new>",
+		)
+
+		Expect(err).To(MatchError("<rename error: <old> -> <new>>"))
+	})
+
+	It("does nothing if the keys are the same", func() {
+		err := RenameHandlerKey(
+			context.Background(),
+			repositoryStub{},
+			"<key>",
+			"<key>",
+		)
+
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("returns an error if the repository does not support renaming", func() {
+		err := RenameHandlerKey(
+			context.Background(),
+			basicRepositoryStub{},
+			"<old>",
+			"<new>",
+		)
+
+		Expect(err).To(Equal(ErrNotSupported))
+	})
+})
+
+var _ = Describe("func ListResources()", func() {
+	It("uses the repository if the handler implements RepositoryAware", func() {
+		var items []Item
+
+		for item, err := range ListResources(
+			context.Background(),
+			&repositoryAwareStub{},
+		) {
+			Expect(err).ShouldNot(HaveOccurred())
+			items = append(items, item)
+		}
+
+		Expect(items).To(Equal([]Item{
+			{Resource: []byte("<resource>"), Version: []byte("<version>")},
+		}))
+	})
+
+	It("yields an error if the repository does not support listing resources", func() {
+		count := 0
+
+		for _, err := range ListResources(
+			context.Background(),
+			&repositoryAwareStub{basic: true},
+		) {
+			Expect(err).To(Equal(ErrNotSupported))
+			count++
+		}
+
+		Expect(count).To(Equal(1))
+	})
+
+	It("yields an error if the handler does not implement RepositoryAware", func() {
+		count := 0
+
+		for _, err := range ListResources(
+			context.Background(),
+			&ProjectionMessageHandlerStub{},
+		) {
+			Expect(err).To(Equal(ErrNotSupported))
+			count++
+		}
+
+		Expect(count).To(Equal(1))
+	})
+})
+
 type repositoryAwareStub struct {
 	ProjectionMessageHandlerStub
+	basic bool
 }
 
-func (*repositoryAwareStub) ResourceRepository(context.Context) (Repository, error) {
+func (h *repositoryAwareStub) ResourceRepository(context.Context) (Repository, error) {
+	if h.basic {
+		return basicRepositoryStub{}, nil
+	}
 	return repositoryStub{}, nil
 }
 
+// basicRepositoryStub is a repository that does not implement any of the
+// optional repository interfaces.
+type basicRepositoryStub struct {
+	Repository
+}
+
 type repositoryStub struct {
 }
 
@@ -108,3 +259,21 @@ func (repositoryStub) UpdateResourceVersion(ctx context.Context, r, c, n []byte)
 func (repositoryStub) DeleteResource(ctx context.Context, r []byte) error {
 	return errors.New("<delete error>")
 }
+
+func (repositoryStub) ListResources(ctx context.Context) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		yield(Item{Resource: []byte("<resource>"), Version: []byte("<version>")}, nil)
+	}
+}
+
+func (repositoryStub) DeleteAllResources(ctx context.Context) error {
+	return errors.New("<reset error>")
+}
+
+func (repositoryStub) RenameHandlerKey(ctx context.Context, o, n string) error {
+	return fmt.Errorf("<rename error: %s -> %s>", o, n)
+}
+
+func (repositoryStub) CheckHealth(ctx context.Context) error {
+	return errors.New("<health error>")
+}
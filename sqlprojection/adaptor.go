@@ -3,9 +3,12 @@ package sqlprojection
 import (
 	"context"
 	"database/sql"
+	"log/slog"
 
+	"github.com/dogmatiq/cosyne"
 	"github.com/dogmatiq/dogma"
 	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/internal/logging"
 	"github.com/dogmatiq/projectionkit/internal/unboundhandler"
 	"github.com/dogmatiq/projectionkit/resource"
 )
@@ -15,7 +18,13 @@ import (
 type adaptor struct {
 	db      *sql.DB
 	handler MessageHandler
+	key     string
 	repo    *ResourceRepository
+	logger  *slog.Logger
+	schema  string
+
+	migrateM cosyne.Mutex
+	migrated uint32
 }
 
 // New returns a new Dogma projection message handler by binding an SQL-specific
@@ -35,15 +44,49 @@ func New(
 		return unboundhandler.New(h)
 	}
 
-	return &adaptor{
+	return newAdaptor(db, h, options...)
+}
+
+// newAdaptor returns an adaptor that binds h to db.
+func newAdaptor(
+	db *sql.DB,
+	h MessageHandler,
+	options ...Option,
+) *adaptor {
+	key := identity.Key(h)
+
+	a := &adaptor{
 		db:      db,
 		handler: h,
+		key:     key,
 		repo: NewResourceRepository(
 			db,
-			identity.Key(h),
+			key,
 			options...,
 		),
 	}
+
+	for _, opt := range options {
+		if opt.applyToAdaptor != nil {
+			opt.applyToAdaptor(a)
+		}
+	}
+
+	if h, ok := h.(ResettableMessageHandler); ok {
+		a.repo.reset = h.Reset
+	}
+
+	if a.schema != "" {
+		a.repo.reset = a.resetHandlerSchema(a.repo.reset)
+	}
+
+	if h, ok := h.(TxOptionsMessageHandler); ok {
+		if opts := h.TxOptions(); opts != nil {
+			a.repo.txOptions = opts
+		}
+	}
+
+	return a
 }
 
 // Configure produces a configuration for this handler by calling methods on
@@ -59,13 +102,24 @@ func (a *adaptor) HandleEvent(
 	s dogma.ProjectionEventScope,
 	m dogma.Event,
 ) (bool, error) {
-	return a.repo.UpdateResourceVersionFn(
+	if err := a.migrate(ctx); err != nil {
+		return false, err
+	}
+
+	ok, err := a.repo.UpdateResourceVersionFn(
 		ctx,
 		r, c, n,
 		func(ctx context.Context, tx *sql.Tx) error {
+			if err := a.setSearchPath(ctx, tx); err != nil {
+				return err
+			}
 			return a.handler.HandleEvent(ctx, tx, s, m)
 		},
 	)
+
+	logging.HandleEvent(ctx, a.logger, a.key, r, c, n, ok, err)
+
+	return ok, err
 }
 
 // ResourceVersion returns the version of the resource r.
@@ -76,12 +130,27 @@ func (a *adaptor) ResourceVersion(ctx context.Context, r []byte) ([]byte, error)
 // CloseResource informs the projection that the resource r will not be
 // used in any future calls to HandleEvent().
 func (a *adaptor) CloseResource(ctx context.Context, r []byte) error {
-	return a.repo.DeleteResource(ctx, r)
+	err := a.repo.DeleteResource(ctx, r)
+	logging.CloseResource(ctx, a.logger, a.key, r, err)
+	return err
 }
 
 // Compact reduces the size of the projection's data.
 func (a *adaptor) Compact(ctx context.Context, s dogma.ProjectionCompactScope) error {
-	return a.handler.Compact(ctx, a.db, s)
+	if err := a.migrate(ctx); err != nil {
+		return err
+	}
+
+	if a.schema != "" {
+		ctx = withSearchPath(ctx, a.schema)
+	}
+
+	ctx = context.WithValue(ctx, compactRepositoryKey{}, a.repo)
+	ctx = a.repo.hookContext(ctx)
+
+	err := a.handler.Compact(ctx, a.db, s)
+	logging.Compact(ctx, a.logger, a.key, err)
+	return err
 }
 
 // ResourceRepository returns a repository that can be used to manipulate the
@@ -1,19 +1,22 @@
 package sqlprojection_test
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/dogmatiq/dogma"
 	. "github.com/dogmatiq/enginekit/enginetest/stubs"
-	"github.com/dogmatiq/projectionkit/internal/adaptortest"
 	"github.com/dogmatiq/projectionkit/internal/identity"
+	"github.com/dogmatiq/projectionkit/resource"
 	. "github.com/dogmatiq/projectionkit/sqlprojection"
 	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
 	"github.com/dogmatiq/sqltest"
+	"github.com/dogmatiq/sqltest/sqlstub"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -28,7 +31,7 @@ var _ = Describe("type adaptor", func() {
 		}
 	})
 
-	for _, pair := range sqltest.CompatiblePairs() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
 		pair := pair // capture loop variable
 
 		When(
@@ -72,8 +75,6 @@ var _ = Describe("type adaptor", func() {
 					cancel()
 				})
 
-				adaptortest.DescribeAdaptor(&ctx, &adaptor)
-
 				Describe("func Configure()", func() {
 					It("forwards to the handler", func() {
 						Expect(identity.Key(adaptor)).To(Equal("<key>"))
@@ -105,6 +106,36 @@ var _ = Describe("type adaptor", func() {
 					})
 				})
 
+				Describe("func ResetHandler()", func() {
+					It("calls the handler's Reset() method", func() {
+						called := false
+						handler.ResetFunc = func(context.Context, *sql.Tx) error {
+							called = true
+							return nil
+						}
+
+						err := resource.ResetHandler(ctx, adaptor)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(called).To(BeTrue())
+					})
+
+					It("does not remove the resource versions if the handler's Reset() method fails", func() {
+						err := resource.StoreVersion(ctx, adaptor, []byte("<resource>"), []byte("<version>"))
+						Expect(err).ShouldNot(HaveOccurred())
+
+						handler.ResetFunc = func(context.Context, *sql.Tx) error {
+							return errors.New("<error>")
+						}
+
+						err = resource.ResetHandler(ctx, adaptor)
+						Expect(err).To(MatchError("<error>"))
+
+						ver, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ver).To(Equal([]byte("<version>")))
+					})
+				})
+
 				Describe("func Compact()", func() {
 					It("forwards to the handler", func() {
 						handler.CompactFunc = func(
@@ -123,6 +154,109 @@ var _ = Describe("type adaptor", func() {
 						Expect(err).To(MatchError("<error>"))
 					})
 				})
+
+				Describe("func WithTxOptions()", func() {
+					handleEvent := func(adaptor dogma.ProjectionMessageHandler) error {
+						_, err := adaptor.HandleEvent(
+							ctx,
+							[]byte("<resource>"),
+							nil,
+							[]byte("<version>"),
+							nil,
+							EventA1,
+						)
+						return err
+					}
+
+					It("uses the options when beginning a transaction", func() {
+						adaptor := New(
+							db,
+							handler,
+							WithTxOptions(&sql.TxOptions{Isolation: sql.LevelSerializable}),
+						)
+
+						err := handleEvent(adaptor)
+						Expect(err).ShouldNot(HaveOccurred())
+					})
+
+					It("returns an error if the driver does not support the options", func() {
+						adaptor := New(
+							db,
+							handler,
+							WithTxOptions(&sql.TxOptions{Isolation: sql.LevelSnapshot}),
+						)
+
+						err := handleEvent(adaptor)
+						Expect(err).To(MatchError("the Snapshot isolation level is not supported"))
+					})
+
+					It("prefers the options provided by the handler", func() {
+						handler.TxOptionsFunc = func() *sql.TxOptions {
+							return &sql.TxOptions{Isolation: sql.LevelSnapshot}
+						}
+
+						adaptor := New(
+							db,
+							handler,
+							WithTxOptions(&sql.TxOptions{Isolation: sql.LevelSerializable}),
+						)
+
+						err := handleEvent(adaptor)
+						Expect(err).To(MatchError("the Snapshot isolation level is not supported"))
+					})
+
+					It("uses the options from the Option if the handler does not provide any", func() {
+						handler.TxOptionsFunc = func() *sql.TxOptions {
+							return nil
+						}
+
+						adaptor := New(
+							db,
+							handler,
+							WithTxOptions(&sql.TxOptions{Isolation: sql.LevelSnapshot}),
+						)
+
+						err := handleEvent(adaptor)
+						Expect(err).To(MatchError("the Snapshot isolation level is not supported"))
+					})
+				})
+
+				Describe("func WithLogger()", func() {
+					It("logs driver selection and handler operations", func() {
+						var buf bytes.Buffer
+						logger := slog.New(
+							slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+						)
+
+						adaptor := New(db, handler, WithLogger(logger))
+
+						ok, err := adaptor.HandleEvent(
+							ctx,
+							[]byte("<resource>"),
+							nil,
+							[]byte("<version>"),
+							nil,
+							EventA1,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeTrue())
+
+						ok, err = adaptor.HandleEvent(
+							ctx,
+							[]byte("<resource>"),
+							nil,
+							[]byte("<version>"),
+							nil,
+							EventA1,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeFalse())
+
+						Expect(buf.String()).To(ContainSubstring(`msg="selected SQL driver"`))
+						Expect(buf.String()).To(ContainSubstring(`msg="handled event" handler=<key> resource=<resource> current_version="" next_version=<version> outcome=applied`))
+						Expect(buf.String()).To(ContainSubstring(`outcome=conflict`))
+					})
+				})
 			},
 		)
 	}
@@ -137,5 +271,22 @@ var _ = Describe("type adaptor", func() {
 			)
 			Expect(err).To(MatchError("projection handler has not been bound to a database"))
 		})
+
+		It("logs failure to select a driver", func() {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+			adaptor := New(
+				sql.OpenDB(&sqlstub.Connector{}),
+				handler,
+				WithLogger(logger),
+			)
+
+			_, err := adaptor.ResourceVersion(context.Background(), []byte("<resource>"))
+			Expect(err).Should(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring(`level=ERROR msg="unable to select SQL driver"`))
+			Expect(buf.String()).To(ContainSubstring(`could not find a driver that is compatible with *sqlstub.Driver`))
+		})
 	})
 })
@@ -0,0 +1,53 @@
+package sqlprojection
+
+import (
+	"context"
+)
+
+// AfterCommit registers fn to be called once the transaction in which an
+// event is being handled has been committed.
+//
+// ctx MUST be the context passed to MessageHandler.HandleEvent() or
+// ResettableMessageHandler.Reset(), or a context derived from it. Otherwise,
+// AfterCommit() panics.
+//
+// Callbacks are called in the order they are registered, before HandleEvent()
+// returns. They are discarded if the transaction is rolled back, including
+// when the resource's version does not match the expected version. If the
+// transaction is retried, only the callbacks registered during the final
+// attempt are called.
+//
+// It is intended for notifying other parts of the application that the
+// projection's data has changed, such as pushing updates to connected
+// clients. Callbacks SHOULD NOT block.
+func AfterCommit(ctx context.Context, fn func()) {
+	c, ok := ctx.Value(afterCommitKey{}).(*afterCommitCallbacks)
+	if !ok {
+		panic("sqlprojection.AfterCommit() must be called with a context passed to HandleEvent() or Reset()")
+	}
+
+	c.fns = append(c.fns, fn)
+}
+
+// afterCommitKey is the context key used to store the callbacks registered by
+// AfterCommit().
+type afterCommitKey struct{}
+
+// afterCommitCallbacks is a collection of callbacks registered by
+// AfterCommit() during a single transaction.
+type afterCommitCallbacks struct {
+	fns []func()
+}
+
+// withAfterCommit returns a context that collects the callbacks registered by
+// AfterCommit() into c.
+func withAfterCommit(ctx context.Context, c *afterCommitCallbacks) context.Context {
+	return context.WithValue(ctx, afterCommitKey{}, c)
+}
+
+// run calls each of the registered callbacks.
+func (c *afterCommitCallbacks) run() {
+	for _, fn := range c.fns {
+		fn()
+	}
+}
@@ -0,0 +1,171 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/projectionkit/resource"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func AfterCommit()", func() {
+	It("panics if the context was not passed to the handler", func() {
+		Expect(func() {
+			AfterCommit(context.Background(), func() {})
+		}).To(PanicWith("sqlprojection.AfterCommit() must be called with a context passed to HandleEvent() or Reset()"))
+	})
+
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+					handler  *fixtures.MessageHandler
+					adaptor  dogma.ProjectionMessageHandler
+					called   []string
+				)
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					called = nil
+
+					handler = &fixtures.MessageHandler{}
+					handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+						c.Identity("<projection>", "<key>")
+					}
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						_ *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						AfterCommit(ctx, func() { called = append(called, "<first>") })
+						AfterCommit(ctx, func() { called = append(called, "<second>") })
+						return nil
+					}
+
+					adaptor = New(db, handler)
+				})
+
+				AfterEach(func() {
+					err := DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("calls the callbacks in order after the transaction is committed", func() {
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						_ *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						AfterCommit(ctx, func() {
+							v, err := adaptor.ResourceVersion(context.Background(), []byte("<resource>"))
+							Expect(err).ShouldNot(HaveOccurred())
+							Expect(v).To(Equal([]byte("<version 01>")))
+
+							called = append(called, "<first>")
+						})
+						AfterCommit(ctx, func() { called = append(called, "<second>") })
+						return nil
+					}
+
+					ok, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version 01>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeTrue())
+					Expect(called).To(Equal([]string{"<first>", "<second>"}))
+				})
+
+				It("does not call the callbacks if the handler returns an error", func() {
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						_ *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						AfterCommit(ctx, func() { called = append(called, "<callback>") })
+						return errors.New("<error>")
+					}
+
+					_, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version 01>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).To(MatchError("<error>"))
+					Expect(called).To(BeEmpty())
+				})
+
+				It("does not call the callbacks if the current version does not match", func() {
+					ok, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						[]byte("<incorrect>"),
+						[]byte("<version 01>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeFalse())
+					Expect(called).To(BeEmpty())
+				})
+
+				It("calls the callbacks registered by Reset()", func() {
+					handler.ResetFunc = func(ctx context.Context, _ *sql.Tx) error {
+						AfterCommit(ctx, func() { called = append(called, "<reset>") })
+						return nil
+					}
+
+					err := resource.ResetHandler(ctx, New(db, handler))
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(called).To(Equal([]string{"<reset>"}))
+				})
+			},
+		)
+	}
+})
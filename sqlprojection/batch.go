@@ -0,0 +1,80 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/internal/logging"
+)
+
+// BatchHandler is a dogma.ProjectionMessageHandler that can apply a sequence of
+// events to a single resource within one transaction.
+//
+// The handlers returned by New() implement BatchHandler unless the database is
+// nil.
+type BatchHandler interface {
+	dogma.ProjectionMessageHandler
+
+	// HandleEventBatch updates the projection to reflect the occurrence of each
+	// of the given events, in order.
+	//
+	// It is equivalent to calling HandleEvent() once for each event, where c is
+	// the current version before the first event and n is the next version
+	// after the last event, except that all of the events are applied within a
+	// single transaction. The intermediate versions are never persisted.
+	//
+	// If c is not the current version of r, it returns false and none of the
+	// events are applied. If an error occurs while applying any of the events,
+	// none of the events are applied and the version of r is unchanged.
+	HandleEventBatch(
+		ctx context.Context,
+		r, c, n []byte,
+		events []BatchEvent,
+	) (bool, error)
+}
+
+// BatchEvent is an event that is applied as part of a batch by
+// BatchHandler.HandleEventBatch().
+type BatchEvent struct {
+	// Scope is the scope passed to the handler when applying the event.
+	Scope dogma.ProjectionEventScope
+
+	// Event is the event to apply.
+	Event dogma.Event
+}
+
+var _ BatchHandler = (*adaptor)(nil)
+
+// HandleEventBatch updates the projection to reflect the occurrence of each of
+// the given events, in order.
+func (a *adaptor) HandleEventBatch(
+	ctx context.Context,
+	r, c, n []byte,
+	events []BatchEvent,
+) (bool, error) {
+	if err := a.migrate(ctx); err != nil {
+		return false, err
+	}
+
+	ok, err := a.repo.UpdateResourceVersionFn(
+		ctx,
+		r, c, n,
+		func(ctx context.Context, tx *sql.Tx) error {
+			if err := a.setSearchPath(ctx, tx); err != nil {
+				return err
+			}
+
+			for _, e := range events {
+				if err := a.handler.HandleEvent(ctx, tx, e.Scope, e.Event); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+
+	logging.HandleEvent(ctx, a.logger, a.key, r, c, n, ok, err)
+
+	return ok, err
+}
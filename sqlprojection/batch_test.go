@@ -0,0 +1,181 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type BatchHandler", func() {
+	var handler *fixtures.MessageHandler
+
+	BeforeEach(func() {
+		handler = &fixtures.MessageHandler{}
+		handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+			c.Identity("<projection>", "<key>")
+		}
+	})
+
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+					adaptor  BatchHandler
+					events   []BatchEvent
+				)
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					adaptor = New(db, handler).(BatchHandler)
+
+					events = []BatchEvent{
+						{Event: EventA1},
+						{Event: EventA2},
+						{Event: EventA3},
+					}
+				})
+
+				AfterEach(func() {
+					err := DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				Describe("func HandleEventBatch()", func() {
+					It("applies each event in order within a single transaction", func() {
+						var (
+							txs     []*sql.Tx
+							applied []dogma.Event
+						)
+
+						handler.HandleEventFunc = func(
+							_ context.Context,
+							tx *sql.Tx,
+							_ dogma.ProjectionEventScope,
+							m dogma.Event,
+						) error {
+							txs = append(txs, tx)
+							applied = append(applied, m)
+							return nil
+						}
+
+						ok, err := adaptor.HandleEventBatch(
+							ctx,
+							[]byte("<resource>"),
+							nil,
+							[]byte("<version 03>"),
+							events,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeTrue())
+
+						Expect(applied).To(Equal([]dogma.Event{EventA1, EventA2, EventA3}))
+						Expect(txs).To(HaveLen(3))
+						Expect(txs[1]).To(BeIdenticalTo(txs[0]))
+						Expect(txs[2]).To(BeIdenticalTo(txs[0]))
+
+						ver, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ver).To(Equal([]byte("<version 03>")))
+					})
+
+					It("returns false without applying any events if the current version is incorrect", func() {
+						handler.HandleEventFunc = func(
+							context.Context,
+							*sql.Tx,
+							dogma.ProjectionEventScope,
+							dogma.Event,
+						) error {
+							Fail("unexpected call")
+							return nil
+						}
+
+						ok, err := adaptor.HandleEventBatch(
+							ctx,
+							[]byte("<resource>"),
+							[]byte("<incorrect>"),
+							[]byte("<version 03>"),
+							events,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeFalse())
+					})
+
+					It("does not update the version if any event fails", func() {
+						ok, err := adaptor.HandleEvent(
+							ctx,
+							[]byte("<resource>"),
+							nil,
+							[]byte("<version 00>"),
+							nil,
+							EventA1,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeTrue())
+
+						handler.HandleEventFunc = func(
+							_ context.Context,
+							_ *sql.Tx,
+							_ dogma.ProjectionEventScope,
+							m dogma.Event,
+						) error {
+							if m == EventA3 {
+								return errors.New("<error>")
+							}
+							return nil
+						}
+
+						_, err = adaptor.HandleEventBatch(
+							ctx,
+							[]byte("<resource>"),
+							[]byte("<version 00>"),
+							[]byte("<version 03>"),
+							events,
+						)
+						Expect(err).To(MatchError("<error>"))
+
+						ver, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ver).To(Equal([]byte("<version 00>")))
+					})
+				})
+			},
+		)
+	}
+})
@@ -0,0 +1,633 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/dogmatiq/projectionkit/resource"
+)
+
+// CockroachDriver is a Driver for CockroachDB.
+//
+// This driver should work with any underlying Go SQL driver that supports
+// PostgreSQL compatible databases and $1-style placeholders.
+//
+// Transactions that fail with a retryable error are retried using
+// CockroachDB's client-side retry protocol, which rolls back to the
+// "cockroach_restart" savepoint rather than beginning a new transaction.
+var CockroachDriver Driver = cockroachDriver{}
+
+type cockroachDriver struct {
+	name tableName
+}
+
+func (d cockroachDriver) withTableName(n tableName) Driver {
+	d.name = n
+	return d
+}
+
+// table returns the name of the table used to store resource versions.
+func (d cockroachDriver) table() tableName {
+	if d.name.Table == "" {
+		return tableName{"projection", "occ"}
+	}
+	return d.name
+}
+
+// occ returns the quoted name of the table used to store resource versions.
+func (d cockroachDriver) occ() string {
+	return d.table().qualified(quoteANSI)
+}
+
+func (d cockroachDriver) Dialect() Dialect {
+	return CockroachDialect
+}
+
+func (d cockroachDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
+	// Verify that we're using CockroachDB and that $1-style placeholders are
+	// supported.
+	row := db.QueryRowContext(
+		ctx,
+		`SELECT version() WHERE 1 = $1`,
+		1,
+	)
+
+	var v string
+	if err := row.Scan(&v); err != nil {
+		return err
+	}
+
+	if !strings.Contains(v, "CockroachDB") {
+		return errors.New("the database is not CockroachDB")
+	}
+
+	return nil
+}
+
+func (d cockroachDriver) CreateSchema(ctx context.Context, db *sql.DB) error {
+	return d.MigrateSchema(ctx, db)
+}
+
+func (d cockroachDriver) DropSchema(ctx context.Context, db *sql.DB) error {
+	if d.name.Table != "" {
+		// The schema may be shared with other tables, so only drop the tables
+		// themselves when a custom name is used.
+		_, err := db.ExecContext(
+			ctx,
+			`DROP TABLE IF EXISTS `+d.occ()+`, `+d.schemaVersionTable()+`, `+d.handlerVersionTable()+`, `+d.outboxTable(),
+		)
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, `DROP SCHEMA IF EXISTS projection CASCADE`)
+	return err
+}
+
+func (d cockroachDriver) MigrateSchema(ctx context.Context, db *sql.DB) error {
+	return migrateSchema(ctx, db, d)
+}
+
+func (d cockroachDriver) MigrateHandlerSchema(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	migrations []Migration,
+) error {
+	return migrateHandlerSchema(ctx, db, d, h, migrations)
+}
+
+func (d cockroachDriver) SchemaVersion(ctx context.Context, db *sql.DB) (int, int, error) {
+	return schemaVersion(ctx, db, d)
+}
+
+// schemaVersionTable returns the quoted name of the table used to record
+// which migrations have been applied.
+func (d cockroachDriver) schemaVersionTable() string {
+	return d.table().withSuffix("_schema_version").qualified(quoteANSI)
+}
+
+// handlerVersionTable returns the quoted name of the table used to record
+// which handler migrations have been applied.
+func (d cockroachDriver) handlerVersionTable() string {
+	return d.table().withSuffix("_handler_version").qualified(quoteANSI)
+}
+
+// outboxTable returns the quoted name of the table used to store outbox
+// records.
+func (d cockroachDriver) outboxTable() string {
+	return d.table().withSuffix("_outbox").qualified(quoteANSI)
+}
+
+func (d cockroachDriver) migrations() []migration {
+	return []migration{
+		{
+			Version: 1,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.occ() + ` (
+					handler  BYTES NOT NULL,
+					resource BYTES NOT NULL,
+					version  BYTES NOT NULL,
+
+					PRIMARY KEY (handler, resource)
+				)`,
+			},
+		},
+		{
+			Version: 2,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.handlerVersionTable() + ` (
+					handler    BYTES NOT NULL,
+					version    INT4 NOT NULL,
+					applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+					PRIMARY KEY (handler, version)
+				)`,
+			},
+		},
+		{
+			Version: 3,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.outboxTable() + ` (
+					id      INT8 NOT NULL DEFAULT unique_rowid(),
+					handler BYTES NOT NULL,
+					topic   TEXT NOT NULL,
+					payload BYTES NOT NULL,
+
+					PRIMARY KEY (handler, id)
+				)`,
+			},
+		},
+	}
+}
+
+// retrySavepoint returns the name of the savepoint used by CockroachDB's
+// client-side retry protocol.
+func (d cockroachDriver) retrySavepoint() string {
+	return "cockroach_restart"
+}
+
+func (d cockroachDriver) lockSchema(context.Context, *sql.Conn) (func() error, error) {
+	// CockroachDB does not support advisory locks. Instead, concurrent
+	// migrations are prevented by its serializable transactions, which cause
+	// competing transactions to fail with a retryable error.
+	return func() error { return nil }, nil
+}
+
+func (d cockroachDriver) createSchemaVersionTable(ctx context.Context, conn *sql.Conn) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if schema := d.table().Schema; schema != "" {
+		_, err = tx.ExecContext(ctx, `CREATE SCHEMA IF NOT EXISTS `+quoteANSI(schema))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+d.schemaVersionTable()+` (
+			version    INT4 NOT NULL PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d cockroachDriver) tableExists(ctx context.Context, q rowQueryer, n tableName) (bool, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (
+			SELECT
+				1
+			FROM information_schema.tables
+			WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema())
+			AND table_name = $2
+		)`,
+		n.Schema,
+		n.Table,
+	)
+
+	var ok bool
+	err := row.Scan(&ok)
+	return ok, err
+}
+
+func (d cockroachDriver) querySchemaVersion(ctx context.Context, q rowQueryer) (int, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(MAX(version), 0)
+		FROM `+d.schemaVersionTable(),
+	)
+
+	var v int
+	err := row.Scan(&v)
+	return v, err
+}
+
+func (d cockroachDriver) storeSchemaVersion(ctx context.Context, tx *sql.Tx, v int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.schemaVersionTable()+` (
+			version
+		) VALUES (
+			$1
+		)`,
+		v,
+	)
+	return err
+}
+
+func (d cockroachDriver) queryHandlerSchemaVersion(ctx context.Context, q rowQueryer, h string) (int, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(MAX(version), 0)
+		FROM `+d.handlerVersionTable()+`
+		WHERE handler = $1`,
+		h,
+	)
+
+	var v int
+	err := row.Scan(&v)
+	return v, err
+}
+
+func (d cockroachDriver) storeHandlerSchemaVersion(ctx context.Context, tx *sql.Tx, h string, v int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.handlerVersionTable()+` (
+			handler,
+			version
+		) VALUES (
+			$1,
+			$2
+		)`,
+		h,
+		v,
+	)
+	return err
+}
+
+func (d cockroachDriver) StoreVersion(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	r, v []byte,
+) error {
+	_, err := db.ExecContext(
+		ctx,
+		`UPSERT INTO `+d.occ()+` (
+			handler,
+			resource,
+			version
+		) VALUES (
+			$1,
+			$2,
+			$3
+		)`,
+		h,
+		r,
+		v,
+	)
+	return err
+}
+
+func (d cockroachDriver) UpdateVersion(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	r, c, n []byte,
+) (bool, error) {
+	// If the "current" version is empty, we assumed it's correct and that there
+	// is no existing entry for this resource.
+	if len(c) == 0 {
+		res, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO `+d.occ()+` (
+				handler,
+				resource,
+				version
+			) VALUES (
+				$1,
+				$2,
+				$3
+			) ON CONFLICT DO NOTHING`,
+			h,
+			r,
+			n,
+		)
+		if err != nil {
+			return false, err
+		}
+
+		// The affected rows will be exactly 1 if the row was inserted.
+		count, err := res.RowsAffected()
+		return count == 1, err
+	}
+
+	var (
+		res sql.Result
+		err error
+	)
+
+	if len(n) == 0 {
+		// If the "next" version is empty, we can delete the row entirely.
+		res, err = tx.ExecContext(
+			ctx,
+			`DELETE FROM `+d.occ()+`
+			WHERE handler = $1
+			AND resource = $2
+			AND version = $3`,
+			h,
+			r,
+			c,
+		)
+	} else {
+		// Otherwise we simply update the existing row.
+		res, err = tx.ExecContext(
+			ctx,
+			`UPDATE `+d.occ()+` SET
+				version = $1
+			WHERE handler = $2
+			AND resource = $3
+			AND version = $4`,
+			n,
+			h,
+			r,
+			c,
+		)
+	}
+
+	if err != nil {
+		// CODE COVERAGE: This branch can not be easily covered without somehow
+		// breaking the SQL connection or the schema in some way.
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	return count != 0, err
+}
+
+func (d cockroachDriver) QueryVersion(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	r []byte,
+) ([]byte, error) {
+	row := db.QueryRowContext(
+		ctx,
+		`SELECT
+			version
+		FROM `+d.occ()+`
+		WHERE handler = $1
+		AND resource = $2`,
+		h,
+		r,
+	)
+
+	var v []byte
+	err := row.Scan(&v)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return v, err
+}
+
+func (d cockroachDriver) QueryVersions(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	a []byte,
+	n int,
+) ([]resource.Item, error) {
+	if a == nil {
+		// A nil slice is treated as NULL, which never compares as less than
+		// any resource.
+		a = []byte{}
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT
+			resource,
+			version
+		FROM `+d.occ()+`
+		WHERE handler = $1
+		AND resource > $2
+		ORDER BY resource
+		LIMIT $3`,
+		h,
+		a,
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []resource.Item
+
+	for rows.Next() {
+		var item resource.Item
+
+		if err := rows.Scan(&item.Resource, &item.Version); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (d cockroachDriver) DeleteResource(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	r []byte,
+) error {
+	_, err := db.ExecContext(
+		ctx,
+		`DELETE FROM `+d.occ()+`
+		WHERE handler = $1
+		AND resource = $2`,
+		h,
+		r,
+	)
+
+	return err
+}
+
+func (d cockroachDriver) DeleteAllResources(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.occ()+`
+		WHERE handler = $1`,
+		h,
+	)
+
+	return err
+}
+
+func (d cockroachDriver) RenameHandlerKey(
+	ctx context.Context,
+	tx *sql.Tx,
+	o, n string,
+) (bool, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT
+			COUNT(*)
+		FROM `+d.occ()+`
+		WHERE handler = $1`,
+		n,
+	)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count != 0 {
+		return false, nil
+	}
+
+	for _, t := range []string{
+		d.occ(),
+		d.outboxTable(),
+		d.handlerVersionTable(),
+	} {
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE `+t+` SET
+				handler = $1
+			WHERE handler = $2`,
+			n,
+			o,
+		); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (d cockroachDriver) EnqueueOutboxRecord(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	topic string,
+	payload []byte,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.outboxTable()+` (
+			handler,
+			topic,
+			payload
+		) VALUES (
+			$1,
+			$2,
+			$3
+		)`,
+		h,
+		topic,
+		payload,
+	)
+	return err
+}
+
+func (d cockroachDriver) ClaimOutboxRecords(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	n int,
+) ([]OutboxRecord, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT
+			id,
+			topic,
+			payload
+		FROM `+d.outboxTable()+`
+		WHERE handler = $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE`,
+		h,
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []OutboxRecord
+
+	for rows.Next() {
+		var rec OutboxRecord
+
+		if err := rows.Scan(&rec.ID, &rec.Topic, &rec.Payload); err != nil {
+			return nil, err
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, rows.Err()
+}
+
+func (d cockroachDriver) DeleteOutboxRecord(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	id int64,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.outboxTable()+`
+		WHERE handler = $1
+		AND id = $2`,
+		h,
+		id,
+	)
+
+	return err
+}
+
+// IsRetryableError returns true if err is a CockroachDB transaction retry
+// error (SQLSTATE 40001).
+func (d cockroachDriver) IsRetryableError(err error) bool {
+	return postgresErrorCode(err) == "40001"
+}
+
+// ValidateTxOptions returns an error if opts specifies an isolation level
+// that is not supported by CockroachDB.
+//
+// CockroachDB accepts the weaker isolation levels supported by PostgreSQL,
+// but runs such transactions as SERIALIZABLE unless they are enabled by the
+// cluster's settings.
+func (d cockroachDriver) ValidateTxOptions(opts *sql.TxOptions) error {
+	return validateTxOptions(
+		opts,
+		sql.LevelReadUncommitted,
+		sql.LevelReadCommitted,
+		sql.LevelRepeatableRead,
+		sql.LevelSerializable,
+	)
+}
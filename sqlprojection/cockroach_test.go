@@ -0,0 +1,171 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// products is the list of database products that the tests are run against.
+var products = append(
+	slices.Clone(sqltest.Products),
+	cockroachDB,
+)
+
+// cockroachDB is the sqltest.Product for CockroachDB.
+//
+// CockroachDB is not part of the sqltest Docker stack, so it is only tested
+// when a DSN is provided for a specific driver, for example, when running the
+// single-node instance in this repository's Docker stack:
+//
+//	export DOGMATIQ_TEST_DSN_COCKROACHDB_PGX="postgres://root@127.0.0.1:26257/defaultdb?sslmode=disable"
+var cockroachDB sqltest.MultiDatabaseProduct = cockroachProduct{}
+
+type cockroachProduct struct{}
+
+func (cockroachProduct) Name() string {
+	return "CockroachDB"
+}
+
+func (p cockroachProduct) IsCompatibleWith(d sqltest.Driver) bool {
+	_, ok := d.(sqltest.PostgresProtocol)
+	return ok && os.Getenv(p.dsnVariable(d)) != ""
+}
+
+func (p cockroachProduct) DefaultDataSource(d sqltest.Driver) (sqltest.DataSource, error) {
+	return nil, fmt.Errorf("the %s environment variable is not set", p.dsnVariable(d))
+}
+
+func (cockroachProduct) CreateDatabase(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx, `CREATE DATABASE "`+name+`"`)
+	return err
+}
+
+func (cockroachProduct) DropDatabase(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx, `DROP DATABASE IF EXISTS "`+name+`" CASCADE`)
+	return err
+}
+
+// dsnVariable returns the name of the environment variable that contains the
+// DSN used to connect to CockroachDB using the driver d.
+func (p cockroachProduct) dsnVariable(d sqltest.Driver) string {
+	return strings.ToUpper("DOGMATIQ_TEST_DSN_" + p.Name() + "_" + d.Name())
+}
+
+var _ = Describe("type cockroachDriver", func() {
+	var handler *fixtures.MessageHandler
+
+	BeforeEach(func() {
+		handler = &fixtures.MessageHandler{}
+		handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+			c.Identity("<projection>", "<key>")
+		}
+	})
+
+	for _, pair := range sqltest.CompatiblePairs(cockroachDB) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+				)
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					err := DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("is selected in preference to the PostgreSQL driver", func() {
+					d, err := SelectDriver(ctx, db, BuiltInDrivers())
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(d.Dialect()).To(Equal(CockroachDialect))
+				})
+
+				It("retries the transaction using the client-side retry protocol", func() {
+					calls := 0
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						tx *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						calls++
+
+						// Causes the transaction to fail with a retryable
+						// error until it has been running for the given
+						// duration, which only happens if it is retried
+						// without beginning a new transaction.
+						_, err := tx.ExecContext(ctx, `SELECT crdb_internal.force_retry('50ms')`)
+						return err
+					}
+
+					adaptor := New(
+						db,
+						handler,
+						WithRetryPolicy(RetryPolicy{
+							MaxAttempts:    100,
+							InitialBackoff: 10 * time.Millisecond,
+							MaxBackoff:     10 * time.Millisecond,
+						}),
+					)
+
+					ok, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeTrue())
+					Expect(calls).To(BeNumerically(">", 1))
+
+					ver, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ver).To(Equal([]byte("<version>")))
+				})
+			},
+		)
+	}
+})
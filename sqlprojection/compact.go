@@ -0,0 +1,224 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// DefaultCompactBatchSize is the maximum number of rows modified by each
+// batch executed by CompactInBatches() when CompactStatement.BatchSize is not
+// positive.
+const DefaultCompactBatchSize = 1000
+
+// CompactStatement is a DELETE or UPDATE statement that is executed in batches
+// by CompactInBatches().
+type CompactStatement struct {
+	// Table is the name of the table to modify, quoted and qualified as
+	// necessary.
+	Table string
+
+	// Set is the body of the SET clause of an UPDATE statement, without the
+	// SET keyword. If it is empty, the rows are deleted instead.
+	Set string
+
+	// Where is the condition that selects the rows to modify, without the
+	// WHERE keyword. If it is empty, all rows are modified.
+	//
+	// When updating rows, Set MUST cause the rows to no longer match the
+	// condition, otherwise the same rows are updated repeatedly.
+	Where string
+
+	// Args are the arguments for any placeholders within Set and Where, in the
+	// order they appear. Placeholders use the syntax of the database in use.
+	Args []any
+
+	// BatchSize is the maximum number of rows modified by each batch. If it is
+	// not positive, DefaultCompactBatchSize is used.
+	BatchSize int
+}
+
+// CompactInBatches executes s repeatedly, modifying at most s.BatchSize rows
+// each time, until no more rows match or ctx's deadline is reached.
+//
+// It is intended for use within MessageHandler.Compact(). Each batch is
+// committed separately, so that if the deadline is reached a future call does
+// not need to compact the same rows. Reaching the deadline is not considered
+// an error.
+//
+// The row limit is applied using LIMIT where the database supports it in
+// DELETE and UPDATE statements. Otherwise, rows are selected using their
+// physical row identifiers: the "ctid" column on PostgreSQL and the "rowid"
+// column on SQLite. SQLite tables created WITHOUT ROWID are therefore not
+// supported.
+//
+// If ctx is the context passed to Compact() by a handler that was created
+// with WithHandlerSchema(), the handler schema is used as the search_path.
+//
+// If ctx is the context passed to Compact() and no options are provided, the
+// Driver and RetryPolicy used by the handler are used. Otherwise, the Driver
+// is selected as per the options, and failed batches are retried as per any
+// WithRetryPolicy() option.
+//
+// It returns the total number of rows modified.
+func CompactInBatches(
+	ctx context.Context,
+	db *sql.DB,
+	s CompactStatement,
+	options ...Option,
+) (int64, error) {
+	rr, ok := ctx.Value(compactRepositoryKey{}).(*ResourceRepository)
+	if !ok || len(options) != 0 {
+		rr = NewResourceRepository(db, "", options...)
+	}
+
+	d, err := rr.cs.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	size := s.BatchSize
+	if size <= 0 {
+		size = DefaultCompactBatchSize
+	}
+
+	query, err := s.query(d.Dialect(), size)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, compactionError(err)
+		}
+
+		var n int64
+
+		if err := rr.retry.do(
+			ctx,
+			d.IsRetryableError,
+			func() error {
+				var err error
+				n, err = s.exec(ctx, db, query)
+				return err
+			},
+		); err != nil {
+			if ctx.Err() != nil {
+				return total, compactionError(ctx.Err())
+			}
+			return total, err
+		}
+
+		total += n
+
+		if n < int64(size) {
+			return total, nil
+		}
+	}
+}
+
+// compactRepositoryKey is the context key used to store the repository of the
+// handler whose Compact() method is being called, such that
+// CompactInBatches() can reuse its Driver and RetryPolicy.
+type compactRepositoryKey struct{}
+
+// query returns the statement that modifies a single batch of at most n rows,
+// using the syntax of the dialect d.
+func (s CompactStatement) query(d Dialect, n int) (string, error) {
+	where := ""
+	if s.Where != "" {
+		where = ` WHERE ` + s.Where
+	}
+
+	limit := ` LIMIT ` + strconv.Itoa(n)
+
+	// statement returns the statement that modifies the rows that match cond.
+	statement := func(cond string) string {
+		if s.Set == "" {
+			return `DELETE FROM ` + s.Table + cond
+		}
+		return `UPDATE ` + s.Table + ` SET ` + s.Set + cond
+	}
+
+	switch d {
+	case MySQLDialect, CockroachDialect:
+		return statement(where + limit), nil
+
+	case PostgresDialect:
+		// PostgreSQL does not support LIMIT in DELETE or UPDATE statements.
+		// The "= ANY(ARRAY(...))" form allows the rows to be found by way of a
+		// TID scan.
+		return statement(
+			` WHERE ctid = ANY(ARRAY(SELECT ctid FROM ` + s.Table + where + limit + `))`,
+		), nil
+
+	case SQLiteDialect:
+		// SQLite only supports LIMIT in DELETE and UPDATE statements when it is
+		// compiled with the SQLITE_ENABLE_UPDATE_DELETE_LIMIT option.
+		return statement(
+			` WHERE rowid IN (SELECT rowid FROM ` + s.Table + where + limit + `)`,
+		), nil
+
+	default:
+		return "", fmt.Errorf("batched compaction is not supported by the %q dialect", d)
+	}
+}
+
+// exec executes a single batch of s using the given query, and returns the
+// number of rows modified.
+//
+// If ctx was passed to MessageHandler.Compact() by a handler created with
+// WithHandlerSchema(), the batch is executed within a transaction that uses
+// the handler schema as its search_path.
+func (s CompactStatement) exec(
+	ctx context.Context,
+	db *sql.DB,
+	query string,
+) (int64, error) {
+	schema, ok := ctx.Value(searchPathKey{}).(string)
+	if !ok {
+		res, err := db.ExecContext(ctx, query, s.Args...)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err := setSearchPath(ctx, tx, schema); err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, query, s.Args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
+}
+
+// compactionError returns the error that CompactInBatches() returns when ctx
+// is done, where err is ctx.Err().
+//
+// It returns nil if ctx's deadline has been reached, as the remaining rows are
+// compacted by a future call.
+func compactionError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	return err
+}
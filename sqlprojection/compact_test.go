@@ -0,0 +1,259 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func CompactInBatches()", func() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+					driver   Driver
+				)
+
+				// count returns the number of rows in the test table that
+				// match the given condition.
+				count := func(cond string) int {
+					row := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM compact_test WHERE `+cond)
+
+					var n int
+					err := row.Scan(&n)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					return n
+				}
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					driver, err = SelectDriver(ctx, db, BuiltInDrivers())
+					Expect(err).ShouldNot(HaveOccurred())
+
+					_, err = db.ExecContext(ctx, `CREATE TABLE compact_test (
+						id      INTEGER NOT NULL PRIMARY KEY,
+						expired INTEGER NOT NULL
+					)`)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					// Rows with an odd ID are expired.
+					for i := 0; i < 25; i++ {
+						_, err = db.ExecContext(
+							ctx,
+							fmt.Sprintf(`INSERT INTO compact_test (id, expired) VALUES (%d, %d)`, i, i%2),
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+					}
+				})
+
+				AfterEach(func() {
+					_, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS compact_test`)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("deletes the matching rows in batches", func() {
+					n, err := CompactInBatches(
+						ctx,
+						db,
+						CompactStatement{
+							Table:     "compact_test",
+							Where:     "expired = 1",
+							BatchSize: 5,
+						},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(n).To(BeEquivalentTo(12))
+					Expect(count("expired = 1")).To(Equal(0))
+					Expect(count("expired = 0")).To(Equal(13))
+				})
+
+				It("updates the matching rows in batches", func() {
+					n, err := CompactInBatches(
+						ctx,
+						db,
+						CompactStatement{
+							Table:     "compact_test",
+							Set:       "expired = 0",
+							Where:     "expired = 1",
+							BatchSize: 5,
+						},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(n).To(BeEquivalentTo(12))
+					Expect(count("expired = 0")).To(Equal(25))
+				})
+
+				It("modifies all rows if there is no condition", func() {
+					n, err := CompactInBatches(
+						ctx,
+						db,
+						CompactStatement{
+							Table:     "compact_test",
+							BatchSize: 10,
+						},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(n).To(BeEquivalentTo(25))
+					Expect(count("1 = 1")).To(Equal(0))
+				})
+
+				It("passes the arguments to the statement", func() {
+					where := "id < $1"
+					if driver.Dialect() == MySQLDialect {
+						where = "id < ?"
+					}
+
+					n, err := CompactInBatches(
+						ctx,
+						db,
+						CompactStatement{
+							Table:     "compact_test",
+							Where:     where,
+							Args:      []any{10},
+							BatchSize: 3,
+						},
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(n).To(BeEquivalentTo(10))
+					Expect(count("1 = 1")).To(Equal(15))
+				})
+
+				It("returns without error if the deadline has been reached", func() {
+					expired, cancel := context.WithDeadline(ctx, time.Now())
+					defer cancel()
+
+					n, err := CompactInBatches(
+						expired,
+						db,
+						CompactStatement{
+							Table: "compact_test",
+						},
+						WithDriver(driver),
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(n).To(BeEquivalentTo(0))
+					Expect(count("1 = 1")).To(Equal(25))
+				})
+
+				It("returns an error if the context is canceled", func() {
+					canceled, cancel := context.WithCancel(ctx)
+					cancel()
+
+					_, err := CompactInBatches(
+						canceled,
+						db,
+						CompactStatement{
+							Table: "compact_test",
+						},
+						WithDriver(driver),
+					)
+					Expect(err).To(Equal(context.Canceled))
+				})
+
+				It("returns an error if the driver's dialect is not supported", func() {
+					_, err := CompactInBatches(
+						ctx,
+						db,
+						CompactStatement{
+							Table: "compact_test",
+						},
+						WithDriver(dialectDriver{driver, "<dialect>"}),
+					)
+					Expect(err).To(MatchError(`batched compaction is not supported by the "<dialect>" dialect`))
+				})
+
+				It("applies the retry policy", func() {
+					d := &countingDriver{Driver: driver}
+
+					_, err := CompactInBatches(
+						ctx,
+						db,
+						CompactStatement{
+							Table: "compact_nonexistent",
+						},
+						WithDriver(d),
+						WithRetryPolicy(RetryPolicy{MaxAttempts: 2}),
+					)
+					Expect(err).Should(HaveOccurred())
+					Expect(d.checks).To(Equal(1))
+				})
+
+				It("uses the handler's driver when called with the context passed to Compact()", func() {
+					handler := &fixtures.MessageHandler{}
+					handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+						c.Identity("<projection>", "<key>")
+					}
+					handler.CompactFunc = func(
+						ctx context.Context,
+						db *sql.DB,
+						_ dogma.ProjectionCompactScope,
+					) error {
+						_, err := CompactInBatches(ctx, db, CompactStatement{Table: "compact_test"})
+						return err
+					}
+
+					adaptor := New(db, handler, WithDriver(dialectDriver{driver, "<dialect>"}))
+
+					err := adaptor.Compact(ctx, nil)
+					Expect(err).To(MatchError(`batched compaction is not supported by the "<dialect>" dialect`))
+					Expect(count("1 = 1")).To(Equal(25))
+				})
+			},
+		)
+	}
+})
+
+// countingDriver is a Driver that reports that every error is retryable, and
+// counts the number of errors it has checked.
+type countingDriver struct {
+	Driver
+	checks int
+}
+
+func (d *countingDriver) IsRetryableError(error) bool {
+	d.checks++
+	return true
+}
+
+// dialectDriver is a Driver that reports a specific dialect.
+type dialectDriver struct {
+	Driver
+	dialect Dialect
+}
+
+func (d dialectDriver) Dialect() Dialect {
+	return d.dialect
+}
@@ -0,0 +1,57 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/conformance"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures"
+	"github.com/dogmatiq/sqltest"
+)
+
+func TestConformance(t *testing.T) {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		t.Run(
+			fmt.Sprintf(
+				"%s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func(t *testing.T) {
+				conformance.RunAdaptorTests(
+					t,
+					func(t *testing.T) dogma.ProjectionMessageHandler {
+						ctx := context.Background()
+
+						database, err := sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						t.Cleanup(func() {
+							database.Close()
+						})
+
+						db, err := database.Open()
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						if err := CreateSchema(ctx, db); err != nil {
+							t.Fatal(err)
+						}
+
+						return New(db, &fixtures.MessageHandler{
+							ConfigureFunc: func(c dogma.ProjectionConfigurer) {
+								c.Identity("<projection>", "<key>")
+							},
+						})
+					},
+				)
+			},
+		)
+	}
+}
@@ -0,0 +1,23 @@
+package sqlprojection
+
+// Dialect identifies the dialect of SQL used by a Driver.
+//
+// It allows a MigratingMessageHandler to supply migrations that are specific
+// to the database in use.
+type Dialect string
+
+const (
+	// CockroachDialect is the dialect used by CockroachDriver. It is largely
+	// compatible with PostgresDialect.
+	CockroachDialect Dialect = "cockroach"
+
+	// MySQLDialect is the dialect used by MySQLDriver. It is also used by
+	// MySQL-compatible databases, such as MariaDB.
+	MySQLDialect Dialect = "mysql"
+
+	// PostgresDialect is the dialect used by PostgresDriver.
+	PostgresDialect Dialect = "postgres"
+
+	// SQLiteDialect is the dialect used by SQLiteDriver.
+	SQLiteDialect Dialect = "sqlite"
+)
@@ -3,10 +3,17 @@ package sqlprojection
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/dogmatiq/projectionkit/resource"
 )
 
 // Driver is an interface for database-specific projection drivers.
 type Driver interface {
+	// Dialect returns the dialect of SQL used by the database.
+	Dialect() Dialect
+
 	// IsCompatibleWith returns nil if this driver can be used to store
 	// projections on db.
 	IsCompatibleWith(ctx context.Context, db *sql.DB) error
@@ -17,6 +24,33 @@ type Driver interface {
 	// DropSchema drops the schema elements required by the driver.
 	DropSchema(ctx context.Context, db *sql.DB) error
 
+	// MigrateSchema creates the schema elements required by the driver, or
+	// upgrades them to the latest version if they already exist.
+	//
+	// It MUST be safe to call concurrently, including from multiple processes.
+	MigrateSchema(ctx context.Context, db *sql.DB) error
+
+	// MigrateHandlerSchema applies any of the given migrations that have not
+	// yet been applied for the handler h.
+	//
+	// It MUST NOT create or upgrade the schema elements required by the
+	// driver. It returns an error if they have not been created, or are not at
+	// the latest version, in which case MigrateSchema() must be called first.
+	//
+	// It MUST be safe to call concurrently, including from multiple processes.
+	MigrateHandlerSchema(
+		ctx context.Context,
+		db *sql.DB,
+		h string,
+		migrations []Migration,
+	) error
+
+	// SchemaVersion returns the version of the schema elements in the database
+	// and the latest version supported by the driver.
+	//
+	// current is 0 if the schema has not been created.
+	SchemaVersion(ctx context.Context, db *sql.DB) (current, latest int, err error)
+
 	// StoreVersion unconditionally updates the version for a specific handler
 	// and resource.
 	//
@@ -44,6 +78,21 @@ type Driver interface {
 		r []byte,
 	) ([]byte, error)
 
+	// QueryVersions returns up to n resources for a specific handler, along
+	// with their versions.
+	//
+	// Only resources that sort after the resource a are returned. If a is
+	// empty the resources are returned from the beginning. Resources are
+	// returned in a consistent order, such that the last resource returned can
+	// be used as a in a subsequent call to fetch the next page.
+	QueryVersions(
+		ctx context.Context,
+		db *sql.DB,
+		h string,
+		a []byte,
+		n int,
+	) ([]resource.Item, error)
+
 	// DeleteResource removes the version for a specific handler and resource.
 	DeleteResource(
 		ctx context.Context,
@@ -51,13 +100,104 @@ type Driver interface {
 		h string,
 		r []byte,
 	) error
+
+	// DeleteAllResources removes the versions of all resources for a specific
+	// handler.
+	DeleteAllResources(
+		ctx context.Context,
+		tx *sql.Tx,
+		h string,
+	) error
+
+	// RenameHandlerKey changes the handler key of all resource versions,
+	// outbox records and handler migration versions stored for the handler o
+	// to n.
+	//
+	// It returns false if there are already resource versions stored for n, in
+	// which case no changes are made.
+	RenameHandlerKey(
+		ctx context.Context,
+		tx *sql.Tx,
+		o, n string,
+	) (bool, error)
+
+	// EnqueueOutboxRecord adds a record with the given topic and payload to
+	// the outbox of a specific handler.
+	EnqueueOutboxRecord(
+		ctx context.Context,
+		tx *sql.Tx,
+		h string,
+		topic string,
+		payload []byte,
+	) error
+
+	// ClaimOutboxRecords returns up to n of the records in the outbox of a
+	// specific handler, ordered by ID. Records enqueued by the same
+	// transaction MUST be returned in the order they were enqueued.
+	//
+	// The records MUST be locked such that no other transaction can claim
+	// them until tx ends.
+	ClaimOutboxRecords(
+		ctx context.Context,
+		tx *sql.Tx,
+		h string,
+		n int,
+	) ([]OutboxRecord, error)
+
+	// DeleteOutboxRecord removes a record from the outbox of a specific
+	// handler.
+	DeleteOutboxRecord(
+		ctx context.Context,
+		tx *sql.Tx,
+		h string,
+		id int64,
+	) error
+
+	// ValidateTxOptions returns an error if the driver does not support
+	// transactions with the given options.
+	//
+	// opts may be nil, in which case the database's default options are used.
+	ValidateTxOptions(opts *sql.TxOptions) error
+
+	// IsRetryableError returns true if err indicates that a transaction failed
+	// due to a transient condition, such as a serialization failure or a
+	// deadlock, and may succeed if it is retried from the beginning.
+	IsRetryableError(err error) bool
 }
 
 // BuiltInDrivers returns a list of the built-in drivers.
+//
+// CockroachDriver precedes PostgresDriver, as CockroachDB is compatible with
+// PostgreSQL's wire protocol.
 func BuiltInDrivers() []Driver {
 	return []Driver{
 		MySQLDriver,
+		CockroachDriver,
 		PostgresDriver,
 		SQLiteDriver,
 	}
 }
+
+// validateTxOptions returns an error if opts specifies a read-only transaction
+// or an isolation level other than those in supported.
+func validateTxOptions(opts *sql.TxOptions, supported ...sql.IsolationLevel) error {
+	if opts == nil {
+		return nil
+	}
+
+	if opts.ReadOnly {
+		return errors.New("read-only transactions are not supported")
+	}
+
+	if opts.Isolation == sql.LevelDefault {
+		return nil
+	}
+
+	for _, l := range supported {
+		if opts.Isolation == l {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("the %s isolation level is not supported", opts.Isolation)
+}
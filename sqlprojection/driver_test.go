@@ -14,7 +14,7 @@ import (
 )
 
 var _ = Describe("type Driver (implementations)", func() {
-	for _, pair := range sqltest.CompatiblePairs() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
 		pair := pair // capture loop variable
 
 		When(
@@ -285,3 +285,42 @@ var _ = Describe("type Driver (implementations)", func() {
 		)
 	}
 })
+
+var _ = Describe("func ValidateTxOptions() (driver implementations)", func() {
+	table.DescribeTable(
+		"it accepts supported options",
+		func(d Driver, opts *sql.TxOptions) {
+			Expect(d.ValidateTxOptions(opts)).To(Succeed())
+		},
+		table.Entry("PostgreSQL default options", PostgresDriver, nil),
+		table.Entry("PostgreSQL default isolation level", PostgresDriver, &sql.TxOptions{}),
+		table.Entry("PostgreSQL read committed", PostgresDriver, &sql.TxOptions{Isolation: sql.LevelReadCommitted}),
+		table.Entry("PostgreSQL repeatable read", PostgresDriver, &sql.TxOptions{Isolation: sql.LevelRepeatableRead}),
+		table.Entry("PostgreSQL serializable", PostgresDriver, &sql.TxOptions{Isolation: sql.LevelSerializable}),
+		table.Entry("CockroachDB default options", CockroachDriver, nil),
+		table.Entry("CockroachDB read committed", CockroachDriver, &sql.TxOptions{Isolation: sql.LevelReadCommitted}),
+		table.Entry("CockroachDB serializable", CockroachDriver, &sql.TxOptions{Isolation: sql.LevelSerializable}),
+		table.Entry("MySQL default options", MySQLDriver, nil),
+		table.Entry("MySQL read uncommitted", MySQLDriver, &sql.TxOptions{Isolation: sql.LevelReadUncommitted}),
+		table.Entry("MySQL read committed", MySQLDriver, &sql.TxOptions{Isolation: sql.LevelReadCommitted}),
+		table.Entry("MySQL repeatable read", MySQLDriver, &sql.TxOptions{Isolation: sql.LevelRepeatableRead}),
+		table.Entry("MySQL serializable", MySQLDriver, &sql.TxOptions{Isolation: sql.LevelSerializable}),
+		table.Entry("SQLite default options", SQLiteDriver, nil),
+		table.Entry("SQLite serializable", SQLiteDriver, &sql.TxOptions{Isolation: sql.LevelSerializable}),
+	)
+
+	table.DescribeTable(
+		"it rejects unsupported options",
+		func(d Driver, opts *sql.TxOptions, expect string) {
+			Expect(d.ValidateTxOptions(opts)).To(MatchError(expect))
+		},
+		table.Entry("PostgreSQL snapshot", PostgresDriver, &sql.TxOptions{Isolation: sql.LevelSnapshot}, "the Snapshot isolation level is not supported"),
+		table.Entry("PostgreSQL read-only", PostgresDriver, &sql.TxOptions{ReadOnly: true}, "read-only transactions are not supported"),
+		table.Entry("CockroachDB snapshot", CockroachDriver, &sql.TxOptions{Isolation: sql.LevelSnapshot}, "the Snapshot isolation level is not supported"),
+		table.Entry("CockroachDB read-only", CockroachDriver, &sql.TxOptions{ReadOnly: true}, "read-only transactions are not supported"),
+		table.Entry("MySQL linearizable", MySQLDriver, &sql.TxOptions{Isolation: sql.LevelLinearizable}, "the Linearizable isolation level is not supported"),
+		table.Entry("MySQL read-only", MySQLDriver, &sql.TxOptions{ReadOnly: true}, "read-only transactions are not supported"),
+		table.Entry("SQLite read committed", SQLiteDriver, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, "the Read Committed isolation level is not supported"),
+		table.Entry("SQLite read-only", SQLiteDriver, &sql.TxOptions{ReadOnly: true}, "read-only transactions are not supported"),
+	)
+})
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/sqlprojection"
 )
 
 // MessageHandler is a test implementation of sql.MessageHandler.
@@ -12,6 +13,8 @@ type MessageHandler struct {
 	ConfigureFunc   func(dogma.ProjectionConfigurer)
 	HandleEventFunc func(context.Context, *sql.Tx, dogma.ProjectionEventScope, dogma.Event) error
 	CompactFunc     func(context.Context, *sql.DB, dogma.ProjectionCompactScope) error
+	ResetFunc       func(context.Context, *sql.Tx) error
+	TxOptionsFunc   func() *sql.TxOptions
 }
 
 // Configure configures the behavior of the engine as it relates to this
@@ -55,3 +58,47 @@ func (h *MessageHandler) Compact(ctx context.Context, db *sql.DB, s dogma.Projec
 
 	return nil
 }
+
+// Reset removes all of the projection's data.
+//
+// If h.ResetFunc is non-nil it returns h.ResetFunc(ctx, tx), otherwise it
+// returns nil.
+func (h *MessageHandler) Reset(ctx context.Context, tx *sql.Tx) error {
+	if h.ResetFunc != nil {
+		return h.ResetFunc(ctx, tx)
+	}
+
+	return nil
+}
+
+// TxOptions returns the options to use when beginning a transaction.
+//
+// If h.TxOptionsFunc is non-nil it returns h.TxOptionsFunc(), otherwise it
+// returns nil.
+func (h *MessageHandler) TxOptions() *sql.TxOptions {
+	if h.TxOptionsFunc != nil {
+		return h.TxOptionsFunc()
+	}
+
+	return nil
+}
+
+// MigratingMessageHandler is a test implementation of
+// sql.MigratingMessageHandler.
+type MigratingMessageHandler struct {
+	MessageHandler
+	MigrationsFunc func(sqlprojection.Dialect) []sqlprojection.Migration
+}
+
+// Migrations returns the migrations that create and evolve the handler's
+// tables.
+//
+// If h.MigrationsFunc is non-nil it returns h.MigrationsFunc(d), otherwise it
+// returns nil.
+func (h *MigratingMessageHandler) Migrations(d sqlprojection.Dialect) []sqlprojection.Migration {
+	if h.MigrationsFunc != nil {
+		return h.MigrationsFunc(d)
+	}
+
+	return nil
+}
@@ -56,6 +56,7 @@ type MessageHandler interface {
 	// The context MAY have a deadline. The implementation SHOULD compact data
 	// using multiple small transactions, such that if the deadline is reached a
 	// future call to Compact() does not need to compact the same data.
+	// CompactInBatches() can be used to do so.
 	//
 	// The engine SHOULD call Compact() repeatedly throughout the lifetime of
 	// the projection. The precise scheduling of calls to Compact() are
@@ -63,6 +64,54 @@ type MessageHandler interface {
 	Compact(ctx context.Context, db *sql.DB, s dogma.ProjectionCompactScope) error
 }
 
+// ResettableMessageHandler is a MessageHandler that removes its projection
+// data when its resource versions are reset.
+//
+// See resource.ResetHandler().
+type ResettableMessageHandler interface {
+	MessageHandler
+
+	// Reset removes all of the projection's data, such that it can be rebuilt
+	// from the beginning.
+	//
+	// Changes to the projection state MUST be performed within the supplied
+	// transaction, which is the same transaction that removes the handler's
+	// resource versions.
+	Reset(ctx context.Context, tx *sql.Tx) error
+}
+
+// TxOptionsMessageHandler is a MessageHandler that requires specific options,
+// such as an isolation level, for the transactions in which its events are
+// handled.
+//
+// The options returned by TxOptions() take precedence over any
+// WithTxOptions() option passed to New().
+type TxOptionsMessageHandler interface {
+	MessageHandler
+
+	// TxOptions returns the options to use when beginning a transaction.
+	//
+	// If it returns nil, the options supplied by the WithTxOptions() option are
+	// used, if any. The options MUST NOT specify a read-only transaction.
+	TxOptions() *sql.TxOptions
+}
+
+// MigratingMessageHandler is a MessageHandler that creates and evolves its
+// own tables by way of versioned migrations.
+//
+// The handlers returned by New() apply any outstanding migrations before
+// handling the first event. See also MigrateHandler().
+type MigratingMessageHandler interface {
+	MessageHandler
+
+	// Migrations returns the migrations that create and evolve the handler's
+	// tables, in the order they must be applied.
+	//
+	// d is the dialect of the database in use. Each call with the same
+	// dialect MUST return equivalent migrations.
+	Migrations(d Dialect) []Migration
+}
+
 // NoCompactBehavior can be embedded in MessageHandler implementations to
 // indicate that the projection does not require its data to be compacted.
 //
@@ -0,0 +1,127 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// Migration is a change to the tables owned by a MigratingMessageHandler.
+type Migration struct {
+	// Version identifies the migration. The first migration has a version of
+	// 1, and each subsequent migration's version is one greater than that of
+	// the migration before it.
+	//
+	// Once a migration has been applied its version MUST NOT be reused for a
+	// different change.
+	Version int
+
+	// Apply applies the migration.
+	//
+	// Changes MUST be performed within the supplied transaction, which is the
+	// same transaction that records the migration as applied. Some databases,
+	// such as MySQL, implicitly commit the transaction when the schema is
+	// modified, so migrations SHOULD be written such that they can be safely
+	// re-applied if they are interrupted.
+	Apply func(ctx context.Context, tx *sql.Tx) error
+}
+
+// MigrateHandler applies any of h's migrations that have not yet been applied
+// to db.
+//
+// The schema elements required by the driver are not created or upgraded. It
+// returns an error if they have not been created, or are not at the latest
+// version; see CreateSchema() and MigrateSchema(). It is safe to call
+// MigrateHandler() concurrently, including from multiple processes.
+//
+// The handlers returned by New() call MigrateHandler() automatically before
+// handling the first event, so it is only necessary to call it directly in
+// order to migrate ahead of time. The same options MUST be passed to both. In
+// particular, the handler schema configured by WithHandlerSchema() is created
+// and the migrations are applied within it.
+//
+// If no candidate drivers are provided all built-in drivers are considered as
+// candidates.
+func MigrateHandler(
+	ctx context.Context,
+	db *sql.DB,
+	h MigratingMessageHandler,
+	options ...Option,
+) error {
+	return newAdaptor(db, h, options...).migrate(ctx)
+}
+
+// validateMigrations returns an error if the versions of the given migrations
+// are not sequential, starting at 1.
+func validateMigrations(migrations []Migration) error {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf(
+				"migration at index %d has a version of %d, expected %d",
+				i,
+				m.Version,
+				i+1,
+			)
+		}
+
+		if m.Apply == nil {
+			return fmt.Errorf("migration %d has a nil Apply function", m.Version)
+		}
+	}
+
+	return nil
+}
+
+// migrate creates the handler schema configured by WithHandlerSchema(), if
+// any, and applies the handler's migrations if it implements
+// MigratingMessageHandler, unless this has already been done by this adaptor.
+func (a *adaptor) migrate(ctx context.Context) error {
+	h, ok := a.handler.(MigratingMessageHandler)
+	if (!ok && a.schema == "") || atomic.LoadUint32(&a.migrated) != 0 {
+		return nil
+	}
+
+	if err := a.migrateM.Lock(ctx); err != nil {
+		return err
+	}
+	defer a.migrateM.Unlock()
+
+	// Ensure that another goroutine did not apply the migrations while we were
+	// waiting to acquire the mutex.
+	if atomic.LoadUint32(&a.migrated) != 0 {
+		return nil
+	}
+
+	ctx = a.repo.hookContext(ctx)
+
+	if err := a.repo.withDriver(ctx, func(d Driver) error {
+		if a.schema != "" {
+			if err := a.createHandlerSchema(ctx, d); err != nil {
+				return err
+			}
+		}
+
+		if !ok {
+			return nil
+		}
+
+		migrations := h.Migrations(d.Dialect())
+		if a.schema != "" {
+			migrations = a.inHandlerSchema(migrations)
+		}
+
+		return d.MigrateHandlerSchema(
+			ctx,
+			a.db,
+			a.key,
+			migrations,
+		)
+	}); err != nil {
+		return err
+	}
+
+	atomic.StoreUint32(&a.migrated, 1)
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/projectionkit/resource"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("type MigratingMessageHandler", func() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx        context.Context
+					cancel     context.CancelFunc
+					database   *sqltest.Database
+					db         *sql.DB
+					handler    *fixtures.MigratingMessageHandler
+					migrations []Migration
+					applied    []int
+				)
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					applied = nil
+					migrations = []Migration{
+						{
+							Version: 1,
+							Apply: func(ctx context.Context, tx *sql.Tx) error {
+								applied = append(applied, 1)
+								_, err := tx.ExecContext(ctx, `CREATE TABLE widget (id INTEGER NOT NULL)`)
+								return err
+							},
+						},
+						{
+							Version: 2,
+							Apply: func(ctx context.Context, tx *sql.Tx) error {
+								applied = append(applied, 2)
+								_, err := tx.ExecContext(ctx, `INSERT INTO widget (id) VALUES (1)`)
+								return err
+							},
+						},
+					}
+
+					handler = &fixtures.MigratingMessageHandler{}
+					handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+						c.Identity("<projection>", "<key>")
+					}
+					handler.MigrationsFunc = func(d Dialect) []Migration {
+						Expect(d).To(BeElementOf(CockroachDialect, MySQLDialect, PostgresDialect, SQLiteDialect))
+						return migrations
+					}
+				})
+
+				AfterEach(func() {
+					_, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS widget`)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				Describe("func MigrateHandler()", func() {
+					It("applies the migrations in order", func() {
+						err := MigrateHandler(ctx, db, handler)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(applied).To(Equal([]int{1, 2}))
+
+						var count int
+						err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM widget`).Scan(&count)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(count).To(Equal(1))
+					})
+
+					It("only applies migrations that have not already been applied", func() {
+						all := migrations
+						migrations = all[:1]
+
+						err := MigrateHandler(ctx, db, handler)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						migrations = all
+
+						err = MigrateHandler(ctx, db, handler)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						err = MigrateHandler(ctx, db, handler)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						Expect(applied).To(Equal([]int{1, 2}))
+					})
+
+					It("tracks migrations separately for each handler", func() {
+						err := MigrateHandler(ctx, db, handler)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						other := &fixtures.MigratingMessageHandler{}
+						other.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+							c.Identity("<other-projection>", "<other-key>")
+						}
+						other.MigrationsFunc = func(Dialect) []Migration {
+							return []Migration{
+								{
+									Version: 1,
+									Apply: func(ctx context.Context, tx *sql.Tx) error {
+										applied = append(applied, -1)
+										return nil
+									},
+								},
+							}
+						}
+
+						err = MigrateHandler(ctx, db, other)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(applied).To(Equal([]int{1, 2, -1}))
+					})
+
+					It("does not record a migration that fails", func() {
+						migrations[1].Apply = func(context.Context, *sql.Tx) error {
+							return errors.New("<error>")
+						}
+
+						err := MigrateHandler(ctx, db, handler)
+						Expect(err).To(MatchError(`unable to apply migration 2 for the "<key>" handler: <error>`))
+
+						migrations[1].Apply = func(context.Context, *sql.Tx) error {
+							applied = append(applied, 2)
+							return nil
+						}
+
+						err = MigrateHandler(ctx, db, handler)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(applied).To(Equal([]int{1, 2}))
+					})
+
+					It("returns an error if the migration versions are not sequential", func() {
+						migrations[1].Version = 3
+
+						err := MigrateHandler(ctx, db, handler)
+						Expect(err).To(MatchError("migration at index 1 has a version of 3, expected 2"))
+						Expect(applied).To(BeEmpty())
+					})
+
+					It("does not re-apply migrations when the handler key is renamed", func() {
+						err := MigrateHandler(ctx, db, handler)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						err = resource.RenameHandlerKey(
+							ctx,
+							NewResourceRepository(db, "<key>"),
+							"<key>",
+							"<renamed>",
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+							c.Identity("<projection>", "<renamed>")
+						}
+
+						err = MigrateHandler(ctx, db, handler)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(applied).To(Equal([]int{1, 2}))
+					})
+
+					It("returns an error if the schema has not been created", func() {
+						err := DropSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						err = MigrateHandler(ctx, db, handler)
+						Expect(err).To(MatchError("the schema has not been created, see CreateSchema()"))
+						Expect(applied).To(BeEmpty())
+					})
+
+					It("does not upgrade a schema that is out of date", func() {
+						opt := WithTableName("", "handler_migration_occ")
+
+						err := CreateSchema(ctx, db, opt)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db, opt)
+
+						_, latest, err := SchemaVersion(ctx, db, opt)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						_, err = db.ExecContext(
+							ctx,
+							fmt.Sprintf(
+								`DELETE FROM handler_migration_occ_schema_version WHERE version = %d`,
+								latest,
+							),
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						err = MigrateHandler(ctx, db, handler, opt)
+						Expect(err).To(MatchError(
+							fmt.Sprintf(
+								"the schema is out of date (version %d, latest is %d), see MigrateSchema()",
+								latest-1,
+								latest,
+							),
+						))
+						Expect(applied).To(BeEmpty())
+
+						current, _, err := SchemaVersion(ctx, db, opt)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(latest - 1))
+					})
+				})
+
+				Describe("func New()", func() {
+					It("applies the migrations before handling the first event", func() {
+						handler.HandleEventFunc = func(
+							ctx context.Context,
+							tx *sql.Tx,
+							_ dogma.ProjectionEventScope,
+							_ dogma.Event,
+						) error {
+							_, err := tx.ExecContext(ctx, `INSERT INTO widget (id) VALUES (2)`)
+							return err
+						}
+
+						adaptor := New(db, handler)
+
+						ok, err := adaptor.HandleEvent(
+							ctx,
+							[]byte("<resource>"),
+							nil,
+							[]byte("<version 01>"),
+							nil,
+							EventA1,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeTrue())
+
+						ok, err = adaptor.HandleEvent(
+							ctx,
+							[]byte("<resource>"),
+							[]byte("<version 01>"),
+							[]byte("<version 02>"),
+							nil,
+							EventA1,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeTrue())
+
+						Expect(applied).To(Equal([]int{1, 2}))
+
+						var count int
+						err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM widget`).Scan(&count)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(count).To(Equal(3))
+					})
+				})
+			},
+		)
+	}
+})
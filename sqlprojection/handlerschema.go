@@ -0,0 +1,201 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// WithHandlerSchema returns an Option that stores the handler's own tables in
+// the PostgreSQL schema with the given name, such that they can be dropped and
+// rebuilt independently of other projections.
+//
+// The schema is created, if necessary, before the handler's migrations are
+// applied. The search_path of each transaction passed to the handler, and to
+// its migrations, is set to the schema using SET LOCAL, so unqualified table
+// names refer to tables within the schema. Tables in other schemas, including
+// "public", must be referred to by their qualified names.
+//
+// MessageHandler.Compact() is passed a database rather than a transaction, so
+// its search_path can not be set. The context passed to Compact() causes
+// CompactInBatches() to set the search_path of each batch. Other statements
+// executed by Compact() should use qualified table names.
+//
+// When the handler is reset, as per resource.ResetHandler(), the schema is
+// dropped and recreated, and the handler's migrations are re-applied, within
+// the same transaction that removes the resource versions. This occurs before
+// ResettableMessageHandler.Reset() is called, if the handler implements it.
+//
+// Statements executed by the Driver within those transactions are also
+// subject to the search_path. The built-in drivers qualify their table names
+// unless WithTableName() is used with an empty schema, in which case the
+// driver's tables, such as those used by EnqueueOutbox(), are resolved within
+// the handler schema.
+//
+// The same option MUST be passed to MigrateHandler() if the handler is migrated
+// ahead of time. It is only supported by the PostgreSQL dialect. The option
+// has no effect when passed to NewResourceRepository().
+//
+// The schema is dropped when the handler is reset, so it MUST NOT be shared
+// with any other tables. It panics if name is empty or "public". An error is
+// returned when the handler is used if name is the schema that contains the
+// OCC table.
+func WithHandlerSchema(name string) Option {
+	if name == "" {
+		panic("sqlprojection.WithHandlerSchema() requires a non-empty schema name")
+	}
+
+	if name == "public" {
+		panic(`sqlprojection.WithHandlerSchema() can not use the "public" schema`)
+	}
+
+	return Option{
+		applyToAdaptor: func(a *adaptor) {
+			a.schema = name
+		},
+	}
+}
+
+// checkHandlerSchemaSupport returns an error if the driver d does not support
+// the handler schema configured by WithHandlerSchema().
+func (a *adaptor) checkHandlerSchemaSupport(d Driver) error {
+	if dialect := d.Dialect(); dialect != PostgresDialect {
+		return fmt.Errorf("handler schemas are not supported by the %q dialect", dialect)
+	}
+
+	if m, ok := d.(schemaMigrator); ok && m.table().Schema == a.schema {
+		return fmt.Errorf("the %q schema contains the OCC table and can not be used as a handler schema", a.schema)
+	}
+
+	return nil
+}
+
+// createHandlerSchema creates the handler schema if it does not already exist.
+func (a *adaptor) createHandlerSchema(ctx context.Context, d Driver) error {
+	if err := a.checkHandlerSchemaSupport(d); err != nil {
+		return err
+	}
+
+	_, err := a.db.ExecContext(
+		ctx,
+		`CREATE SCHEMA IF NOT EXISTS `+quoteANSI(a.schema),
+	)
+	return err
+}
+
+// setSearchPath sets the search_path of tx to the handler schema, if one is
+// configured.
+func (a *adaptor) setSearchPath(ctx context.Context, tx *sql.Tx) error {
+	if a.schema == "" {
+		return nil
+	}
+
+	return setSearchPath(ctx, tx, a.schema)
+}
+
+// setSearchPath sets the search_path of tx to the given schema.
+func setSearchPath(ctx context.Context, tx *sql.Tx, schema string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`SET LOCAL search_path TO `+quoteANSI(schema),
+	)
+	return err
+}
+
+// inHandlerSchema returns a copy of the given migrations that set the
+// search_path to the handler schema before they are applied.
+func (a *adaptor) inHandlerSchema(migrations []Migration) []Migration {
+	result := make([]Migration, len(migrations))
+
+	for i, m := range migrations {
+		apply := m.Apply
+		if apply != nil {
+			m.Apply = func(ctx context.Context, tx *sql.Tx) error {
+				if err := a.setSearchPath(ctx, tx); err != nil {
+					return err
+				}
+				return apply(ctx, tx)
+			}
+		}
+		result[i] = m
+	}
+
+	return result
+}
+
+// resetHandlerSchema returns a function that drops and recreates the handler
+// schema within tx, then calls reset, if it is non-nil.
+//
+// The handler's migrations are re-applied up to the version that is recorded
+// for the handler, such that the recorded version remains accurate.
+func (a *adaptor) resetHandlerSchema(
+	reset func(context.Context, *sql.Tx) error,
+) func(context.Context, *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		d, err := a.repo.cs.resolve(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := a.checkHandlerSchemaSupport(d); err != nil {
+			return err
+		}
+
+		schema := quoteANSI(a.schema)
+
+		if _, err := tx.ExecContext(ctx, `DROP SCHEMA IF EXISTS `+schema+` CASCADE`); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `CREATE SCHEMA `+schema); err != nil {
+			return err
+		}
+
+		if err := a.setSearchPath(ctx, tx); err != nil {
+			return err
+		}
+
+		if h, ok := a.handler.(MigratingMessageHandler); ok {
+			m, ok := d.(schemaMigrator)
+			if !ok {
+				return fmt.Errorf("unable to reset the handler schema: %T does not record handler migrations", d)
+			}
+
+			v, err := m.queryHandlerSchemaVersion(ctx, tx, a.key)
+			if err != nil {
+				return err
+			}
+
+			migrations := h.Migrations(d.Dialect())
+			if err := validateMigrations(migrations); err != nil {
+				return err
+			}
+
+			if v > len(migrations) {
+				v = len(migrations)
+			}
+
+			for _, mig := range migrations[:v] {
+				if err := mig.Apply(ctx, tx); err != nil {
+					return fmt.Errorf("unable to re-apply migration %d for the %q handler: %w", mig.Version, a.key, err)
+				}
+			}
+		}
+
+		if reset != nil {
+			return reset(ctx, tx)
+		}
+
+		return nil
+	}
+}
+
+// searchPathKey is the context key used to store the schema that
+// CompactInBatches() uses as the search_path of each batch.
+type searchPathKey struct{}
+
+// withSearchPath returns a context that causes CompactInBatches() to set the
+// search_path of each batch to the given schema.
+func withSearchPath(ctx context.Context, schema string) context.Context {
+	return context.WithValue(ctx, searchPathKey{}, schema)
+}
@@ -0,0 +1,248 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	"github.com/dogmatiq/projectionkit/resource"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func WithHandlerSchema()", func() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+					driver   Driver
+					handler  *fixtures.MigratingMessageHandler
+					adaptor  dogma.ProjectionMessageHandler
+				)
+
+				// handle calls adaptor.HandleEvent() with the next version of
+				// the resource.
+				version := 0
+				handle := func() error {
+					c := []byte(fmt.Sprintf("<version %02d>", version))
+					if version == 0 {
+						c = nil
+					}
+					version++
+					n := []byte(fmt.Sprintf("<version %02d>", version))
+
+					ok, err := adaptor.HandleEvent(ctx, []byte("<resource>"), c, n, nil, EventA1)
+					if err == nil && !ok {
+						err = fmt.Errorf("OCC conflict at version %d", version)
+					}
+					return err
+				}
+
+				// count returns the number of rows in the widget table within
+				// the handler schema.
+				count := func() int {
+					var n int
+					err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM handler_schema_test.widget`).Scan(&n)
+					Expect(err).ShouldNot(HaveOccurred())
+					return n
+				}
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					driver, err = SelectDriver(ctx, db, BuiltInDrivers())
+					Expect(err).ShouldNot(HaveOccurred())
+
+					version = 0
+
+					handler = &fixtures.MigratingMessageHandler{}
+					handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+						c.Identity("<projection>", "<key>")
+					}
+					handler.MigrationsFunc = func(Dialect) []Migration {
+						return []Migration{
+							{
+								Version: 1,
+								Apply: func(ctx context.Context, tx *sql.Tx) error {
+									_, err := tx.ExecContext(ctx, `CREATE TABLE widget (id INTEGER NOT NULL)`)
+									return err
+								},
+							},
+						}
+					}
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						tx *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						_, err := tx.ExecContext(ctx, `INSERT INTO widget (id) VALUES (1)`)
+						return err
+					}
+
+					adaptor = New(db, handler, WithHandlerSchema("handler_schema_test"))
+				})
+
+				AfterEach(func() {
+					if driver.Dialect() == PostgresDialect {
+						_, err := db.ExecContext(ctx, `DROP SCHEMA IF EXISTS handler_schema_test CASCADE`)
+						Expect(err).ShouldNot(HaveOccurred())
+					}
+
+					err := DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("stores the handler's tables within the schema", func() {
+					if driver.Dialect() != PostgresDialect {
+						Skip("handler schemas are only supported by PostgreSQL")
+					}
+
+					err := handle()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = handle()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					Expect(count()).To(Equal(2))
+				})
+
+				It("creates the handler's tables within the schema when migrated ahead of time", func() {
+					if driver.Dialect() != PostgresDialect {
+						Skip("handler schemas are only supported by PostgreSQL")
+					}
+
+					err := MigrateHandler(ctx, db, handler, WithHandlerSchema("handler_schema_test"))
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = handle()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					Expect(count()).To(Equal(1))
+				})
+
+				It("drops and recreates the schema when the handler is reset", func() {
+					if driver.Dialect() != PostgresDialect {
+						Skip("handler schemas are only supported by PostgreSQL")
+					}
+
+					err := handle()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					called := false
+					handler.ResetFunc = func(ctx context.Context, tx *sql.Tx) error {
+						called = true
+						_, err := tx.ExecContext(ctx, `INSERT INTO widget (id) VALUES (2)`)
+						return err
+					}
+
+					err = resource.ResetHandler(ctx, adaptor)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(called).To(BeTrue())
+					Expect(count()).To(Equal(1))
+
+					version = 0
+					err = handle()
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(count()).To(Equal(2))
+				})
+
+				It("sets the search_path used by CompactInBatches()", func() {
+					if driver.Dialect() != PostgresDialect {
+						Skip("handler schemas are only supported by PostgreSQL")
+					}
+
+					handler.CompactFunc = func(
+						ctx context.Context,
+						db *sql.DB,
+						_ dogma.ProjectionCompactScope,
+					) error {
+						_, err := CompactInBatches(ctx, db, CompactStatement{Table: "widget"})
+						return err
+					}
+
+					err := handle()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = adaptor.Compact(ctx, nil)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(count()).To(Equal(0))
+				})
+
+				It("returns an error if the schema contains the OCC table", func() {
+					if driver.Dialect() != PostgresDialect {
+						Skip("handler schemas are only supported by PostgreSQL")
+					}
+
+					adaptor = New(db, handler, WithHandlerSchema("projection"))
+
+					expect := `the "projection" schema contains the OCC table and can not be used as a handler schema`
+
+					err := handle()
+					Expect(err).To(MatchError(expect))
+
+					err = resource.ResetHandler(ctx, adaptor)
+					Expect(err).To(MatchError(expect))
+
+					_, err = NewResourceRepository(db, "<key>").ResourceVersion(ctx, []byte("<resource>"))
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				It("returns an error if the dialect is not supported", func() {
+					if driver.Dialect() == PostgresDialect {
+						Skip("handler schemas are supported by PostgreSQL")
+					}
+
+					err := handle()
+					Expect(err).To(MatchError(
+						fmt.Sprintf("handler schemas are not supported by the %q dialect", driver.Dialect()),
+					))
+				})
+			},
+		)
+	}
+
+	It("panics if the schema name is empty", func() {
+		Expect(func() {
+			WithHandlerSchema("")
+		}).To(PanicWith("sqlprojection.WithHandlerSchema() requires a non-empty schema name"))
+	})
+
+	It("panics if the schema is the public schema", func() {
+		Expect(func() {
+			WithHandlerSchema("public")
+		}).To(PanicWith(`sqlprojection.WithHandlerSchema() can not use the "public" schema`))
+	})
+})
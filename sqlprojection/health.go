@@ -0,0 +1,101 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Health describes the state of the database used to store a handler's
+// resource versions, as determined by ResourceRepository.Health().
+type Health struct {
+	// Driver is the driver that is used to perform SQL operations.
+	Driver Driver
+
+	// Dialect is the SQL dialect used by Driver.
+	Dialect Dialect
+
+	// SchemaVersion is the version of the schema elements in the database. It
+	// is 0 if the schema has not been created.
+	SchemaVersion int
+
+	// LatestSchemaVersion is the latest schema version supported by Driver.
+	LatestSchemaVersion int
+}
+
+// CheckHealth checks the health of the database used to store the resource
+// versions of the handler with the given key.
+//
+// It is a convenience for NewResourceRepository(db, key, options...)
+// followed by a call to ResourceRepository.Health().
+func CheckHealth(
+	ctx context.Context,
+	db *sql.DB,
+	key string,
+	options ...Option,
+) (Health, error) {
+	return NewResourceRepository(db, key, options...).Health(ctx)
+}
+
+// CheckHealth returns an error if the database can not be reached, or is not in
+// a state that allows resource versions to be stored.
+//
+// It is equivalent to Health(), without the report.
+func (rr *ResourceRepository) CheckHealth(ctx context.Context) error {
+	_, err := rr.Health(ctx)
+	return err
+}
+
+// Health checks the health of the database and reports the driver that is
+// used to access it.
+//
+// It returns an error if the database can not be reached, if no driver is
+// compatible with it, if the schema has not been created or is out of date,
+// or if the handler's resource versions can not be read. Reading the resource
+// versions verifies that the OCC table exists with the columns that the driver
+// expects. At most one row is read, making it suitable for use in readiness
+// probes.
+//
+// The returned Health is populated as far as the checks progressed, even if
+// an error is returned.
+func (rr *ResourceRepository) Health(ctx context.Context) (Health, error) {
+	ctx = rr.hookContext(ctx)
+
+	var h Health
+
+	if err := rr.db.PingContext(ctx); err != nil {
+		return h, fmt.Errorf("unable to connect to the database: %w", err)
+	}
+
+	d, err := rr.cs.resolve(ctx)
+	if err != nil {
+		return h, err
+	}
+
+	h.Driver = d
+	h.Dialect = d.Dialect()
+
+	h.SchemaVersion, h.LatestSchemaVersion, err = d.SchemaVersion(ctx, rr.db)
+	if err != nil {
+		return h, fmt.Errorf("unable to query the schema version: %w", err)
+	}
+
+	if h.SchemaVersion == 0 {
+		return h, errors.New("the schema has not been created, see CreateSchema()")
+	}
+
+	if h.SchemaVersion < h.LatestSchemaVersion {
+		return h, fmt.Errorf(
+			"the schema is out of date (version %d, latest is %d), see MigrateSchema()",
+			h.SchemaVersion,
+			h.LatestSchemaVersion,
+		)
+	}
+
+	if _, err := d.QueryVersions(ctx, rr.db, rr.key, nil, 1); err != nil {
+		return h, fmt.Errorf("unable to read from the OCC table: %w", err)
+	}
+
+	return h, nil
+}
@@ -0,0 +1,101 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	"github.com/dogmatiq/projectionkit/resource"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func CheckHealth()", func() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+				)
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					err := database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("reports the selected driver and the schema version", func() {
+					err := CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+					defer DropSchema(ctx, db) // nolint:errcheck
+
+					expected, err := SelectDriver(ctx, db, BuiltInDrivers())
+					Expect(err).ShouldNot(HaveOccurred())
+
+					h, err := CheckHealth(ctx, db, "<key>")
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(h.Driver).To(Equal(expected))
+					Expect(h.Dialect).To(Equal(expected.Dialect()))
+					Expect(h.SchemaVersion).To(BeNumerically(">", 0))
+					Expect(h.SchemaVersion).To(Equal(h.LatestSchemaVersion))
+				})
+
+				It("returns an error if the schema has not been created", func() {
+					h, err := CheckHealth(ctx, db, "<key>")
+					Expect(err).To(MatchError("the schema has not been created, see CreateSchema()"))
+					Expect(h.Driver).NotTo(BeNil())
+					Expect(h.SchemaVersion).To(Equal(0))
+				})
+
+				It("returns an error if the database can not be reached", func() {
+					err := db.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					_, err = CheckHealth(ctx, db, "<key>")
+					Expect(err).To(MatchError(ContainSubstring("unable to connect to the database")))
+				})
+
+				It("is used by resource.CheckHealth()", func() {
+					err := CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+					defer DropSchema(ctx, db) // nolint:errcheck
+
+					handler := &fixtures.MessageHandler{}
+					handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+						c.Identity("<projection>", "<key>")
+					}
+
+					err = resource.CheckHealth(ctx, New(db, handler))
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+			},
+		)
+	}
+})
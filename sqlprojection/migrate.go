@@ -0,0 +1,298 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"go.uber.org/multierr"
+)
+
+// migration is a change to the schema elements required by a built-in driver.
+type migration struct {
+	// Version is the schema version after the migration has been applied.
+	// Versions start at 1 and each migration's version is one greater than
+	// that of the migration before it.
+	Version int
+
+	// Statements are the statements that apply the migration. They are
+	// executed in order, within the transaction that records the new schema
+	// version.
+	//
+	// Some databases, notably MySQL, commit DDL statements implicitly, in
+	// which case the statements are not applied atomically. If the migration
+	// fails part-way through, the statements that have already been executed
+	// remain in effect but the schema version is not recorded, so the
+	// migration is applied again by the next call to MigrateSchema(). The
+	// statements of such drivers MUST produce the same result when they are
+	// executed again after any one of them has failed.
+	Statements []string
+}
+
+// apply executes the migration's statements within tx.
+func (m migration) apply(ctx context.Context, tx *sql.Tx) error {
+	for _, s := range m.Statements {
+		if _, err := tx.ExecContext(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// schemaMigrator is an interface for the built-in drivers, which share a
+// common implementation of the Driver methods that manage schema versions.
+type schemaMigrator interface {
+	Driver
+
+	// migrations returns the driver's migrations, in the order they must be
+	// applied.
+	migrations() []migration
+
+	// lockSchema acquires an exclusive lock that prevents other processes from
+	// migrating the schema. The lock is held by conn until the returned
+	// function is called.
+	lockSchema(ctx context.Context, conn *sql.Conn) (func() error, error)
+
+	// createSchemaVersionTable creates the table that records which
+	// migrations have been applied, if it does not already exist.
+	createSchemaVersionTable(ctx context.Context, conn *sql.Conn) error
+
+	// table returns the name of the OCC table. The names of the driver's other
+	// tables are derived from it.
+	table() tableName
+
+	// tableExists returns true if the table with the given name exists.
+	tableExists(ctx context.Context, q rowQueryer, t tableName) (bool, error)
+
+	// querySchemaVersion returns the version of the most recently applied
+	// migration, or 0 if no migrations have been applied.
+	querySchemaVersion(ctx context.Context, q rowQueryer) (int, error)
+
+	// storeSchemaVersion records that the migration with version v has been
+	// applied.
+	storeSchemaVersion(ctx context.Context, tx *sql.Tx, v int) error
+
+	// queryHandlerSchemaVersion returns the version of the most recently
+	// applied migration for the handler h, or 0 if no migrations have been
+	// applied.
+	queryHandlerSchemaVersion(ctx context.Context, q rowQueryer, h string) (int, error)
+
+	// storeHandlerSchemaVersion records that the migration with version v has
+	// been applied for the handler h.
+	storeHandlerSchemaVersion(ctx context.Context, tx *sql.Tx, h string, v int) error
+}
+
+// rowQueryer is an interface for types that can query a single row, such as
+// *sql.DB, *sql.Conn and *sql.Tx.
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// migrateSchema applies any of m's migrations that have not yet been applied to
+// db.
+func migrateSchema(ctx context.Context, db *sql.DB, m schemaMigrator) error {
+	return withSchemaLock(ctx, db, m, func(conn *sql.Conn) error {
+		// Even while holding the lock, statements may fail due to contention
+		// with other transactions, notably on SQLite, which does not support
+		// advisory locks.
+		retry := func(fn func() error) error {
+			return DefaultRetryPolicy.do(ctx, m.IsRetryableError, fn)
+		}
+
+		if err := retry(func() error {
+			return m.createSchemaVersionTable(ctx, conn)
+		}); err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations() {
+			if err := retry(func() error {
+				return applyMigration(
+					ctx,
+					conn,
+					mig.Version,
+					func(ctx context.Context, tx *sql.Tx) (int, error) {
+						return m.querySchemaVersion(ctx, tx)
+					},
+					mig.apply,
+					func(ctx context.Context, tx *sql.Tx) error {
+						return m.storeSchemaVersion(ctx, tx, mig.Version)
+					},
+				)
+			}); err != nil {
+				return fmt.Errorf("unable to apply schema migration %d: %w", mig.Version, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// migrateHandlerSchema applies any of the given migrations that have not yet
+// been applied for the handler h.
+//
+// It does not apply m's own migrations, as some of them must be applied while
+// no projections are being updated. It returns an error if the schema has not
+// been created, or is not at the latest version.
+func migrateHandlerSchema(
+	ctx context.Context,
+	db *sql.DB,
+	m schemaMigrator,
+	h string,
+	migrations []Migration,
+) error {
+	if err := validateMigrations(migrations); err != nil {
+		return err
+	}
+
+	return withSchemaLock(ctx, db, m, func(conn *sql.Conn) error {
+		if err := checkSchemaVersion(ctx, conn, m); err != nil {
+			return err
+		}
+
+		for _, mig := range migrations {
+			if err := DefaultRetryPolicy.do(
+				ctx,
+				m.IsRetryableError,
+				func() error {
+					return applyMigration(
+						ctx,
+						conn,
+						mig.Version,
+						func(ctx context.Context, tx *sql.Tx) (int, error) {
+							return m.queryHandlerSchemaVersion(ctx, tx, h)
+						},
+						mig.Apply,
+						func(ctx context.Context, tx *sql.Tx) error {
+							return m.storeHandlerSchemaVersion(ctx, tx, h, mig.Version)
+						},
+					)
+				},
+			); err != nil {
+				return fmt.Errorf("unable to apply migration %d for the %q handler: %w", mig.Version, h, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// checkSchemaVersion returns an error if the schema elements required by m
+// have not been created, or are not at the latest version.
+func checkSchemaVersion(ctx context.Context, q rowQueryer, m schemaMigrator) error {
+	current, latest, err := schemaVersion(ctx, q, m)
+	if err != nil {
+		return err
+	}
+
+	if current == 0 {
+		return errors.New("the schema has not been created, see CreateSchema()")
+	}
+
+	if current < latest {
+		return fmt.Errorf(
+			"the schema is out of date (version %d, latest is %d), see MigrateSchema()",
+			current,
+			latest,
+		)
+	}
+
+	return nil
+}
+
+// withSchemaLock acquires m's schema lock, then calls fn with the connection
+// that holds the lock.
+func withSchemaLock(
+	ctx context.Context,
+	db *sql.DB,
+	m schemaMigrator,
+	fn func(*sql.Conn) error,
+) (err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.lockSchema(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("unable to acquire schema migration lock: %w", err)
+	}
+	defer func() {
+		err = multierr.Append(err, unlock())
+	}()
+
+	return fn(conn)
+}
+
+// applyMigration applies the migration with version v to the database, unless
+// it has already been applied.
+//
+// query returns the version of the most recently applied migration. apply
+// applies the migration, and store records that it has been applied.
+func applyMigration(
+	ctx context.Context,
+	conn *sql.Conn,
+	v int,
+	query func(context.Context, *sql.Tx) (int, error),
+	apply func(context.Context, *sql.Tx) error,
+	store func(context.Context, *sql.Tx) error,
+) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	// The version is checked within the transaction, so that the migration is
+	// never applied twice, even if the lock is not effective.
+	current, err := query(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if current >= v {
+		return nil
+	}
+
+	if err := apply(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := store(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// schemaVersion returns the current schema version of the database queried by
+// q, and the latest version known to m.
+func schemaVersion(
+	ctx context.Context,
+	q rowQueryer,
+	m schemaMigrator,
+) (current, latest int, err error) {
+	if migrations := m.migrations(); len(migrations) != 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	ok, err := m.tableExists(ctx, q, m.table().withSuffix("_schema_version"))
+	if !ok || err != nil {
+		return 0, latest, err
+	}
+
+	current, err = m.querySchemaVersion(ctx, q)
+	return current, latest, err
+}
+
+// schemaLockID returns a 64-bit identifier for the lock that guards
+// migrations of the schema that uses the table with the given name.
+func schemaLockID(table string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte("projectionkit:" + table)) // nolint:errcheck
+	return h.Sum64()
+}
@@ -2,7 +2,13 @@ package sqlprojection
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dogmatiq/projectionkit/resource"
 )
 
 // MySQLDriver is a Driver for MySQL.
@@ -11,9 +17,33 @@ import (
 // compatible databases and ?-style placeholders.
 var MySQLDriver Driver = mysqlDriver{}
 
-type mysqlDriver struct{}
+type mysqlDriver struct {
+	name tableName
+}
+
+func (d mysqlDriver) withTableName(n tableName) Driver {
+	d.name = n
+	return d
+}
+
+// table returns the name of the table used to store resource versions.
+func (d mysqlDriver) table() tableName {
+	if d.name.Table == "" {
+		return tableName{"", "projection_occ"}
+	}
+	return d.name
+}
+
+// occ returns the quoted name of the table used to store resource versions.
+func (d mysqlDriver) occ() string {
+	return d.table().qualified(quoteMySQL)
+}
+
+func (d mysqlDriver) Dialect() Dialect {
+	return MySQLDialect
+}
 
-func (mysqlDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
+func (d mysqlDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
 	// Verify that ?-style placeholders are supported.
 	_, err := db.ExecContext(
 		ctx,
@@ -33,26 +63,416 @@ func (mysqlDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
 	return err
 }
 
-func (mysqlDriver) CreateSchema(ctx context.Context, db *sql.DB) error {
+func (d mysqlDriver) CreateSchema(ctx context.Context, db *sql.DB) error {
+	return d.MigrateSchema(ctx, db)
+}
+
+func (d mysqlDriver) DropSchema(ctx context.Context, db *sql.DB) error {
 	_, err := db.ExecContext(
 		ctx,
-		`CREATE TABLE IF NOT EXISTS projection_occ (
-			handler  VARBINARY(255) NOT NULL,
-			resource VARBINARY(255) NOT NULL,
-			version  VARBINARY(255) NOT NULL,
+		`DROP TABLE IF EXISTS
+			`+d.occ()+`,
+			`+d.schemaVersionTable()+`,
+			`+d.handlerVersionTable()+`,
+			`+d.outboxTable()+`,
+			`+d.migratingOCC()+`,
+			`+d.legacyOCC()+`,
+			`+d.migratingHandlerVersionTable()+`,
+			`+d.legacyHandlerVersionTable(),
+	)
+	return err
+}
+
+func (d mysqlDriver) MigrateSchema(ctx context.Context, db *sql.DB) error {
+	return migrateSchema(ctx, db, d)
+}
+
+func (d mysqlDriver) MigrateHandlerSchema(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	migrations []Migration,
+) error {
+	return migrateHandlerSchema(ctx, db, d, h, migrations)
+}
+
+func (d mysqlDriver) SchemaVersion(ctx context.Context, db *sql.DB) (int, int, error) {
+	return schemaVersion(ctx, db, d)
+}
+
+// schemaVersionTable returns the quoted name of the table used to record
+// which migrations have been applied.
+func (d mysqlDriver) schemaVersionTable() string {
+	return d.table().withSuffix("_schema_version").qualified(quoteMySQL)
+}
+
+// handlerVersionTable returns the quoted name of the table used to record
+// which handler migrations have been applied.
+func (d mysqlDriver) handlerVersionTable() string {
+	return d.table().withSuffix("_handler_version").qualified(quoteMySQL)
+}
+
+// outboxTable returns the quoted name of the table used to store outbox
+// records.
+func (d mysqlDriver) outboxTable() string {
+	return d.table().withSuffix("_outbox").qualified(quoteMySQL)
+}
+
+// migratingOCC returns the quoted name of the table that replaces the OCC
+// table during schema migration 3.
+func (d mysqlDriver) migratingOCC() string {
+	return d.table().withSuffix("_migrating").qualified(quoteMySQL)
+}
+
+// legacyOCC returns the quoted name of the table that holds the original OCC
+// table during schema migration 3, before it is dropped.
+func (d mysqlDriver) legacyOCC() string {
+	return d.table().withSuffix("_legacy").qualified(quoteMySQL)
+}
+
+// migratingHandlerVersionTable returns the quoted name of the table that
+// replaces the handler version table during schema migration 5.
+func (d mysqlDriver) migratingHandlerVersionTable() string {
+	return d.table().withSuffix("_handler_version_migrating").qualified(quoteMySQL)
+}
+
+// legacyHandlerVersionTable returns the quoted name of the table that holds
+// the original handler version table during schema migration 5, before it is
+// dropped.
+func (d mysqlDriver) legacyHandlerVersionTable() string {
+	return d.table().withSuffix("_handler_version_legacy").qualified(quoteMySQL)
+}
+
+// migrationTrigger returns the quoted name of the trigger that copies changes
+// made to the OCC table by the given type of statement to the table that
+// replaces it during schema migration 3.
+func (d mysqlDriver) migrationTrigger(event string) string {
+	return d.table().withSuffix("_migrate_" + event).qualified(quoteMySQL)
+}
+
+// mysqlDigest returns the SHA-256 digest of v, which is used in place of
+// variable-length values within primary keys.
+//
+// It produces the same result as UNHEX(SHA2(v, 256)) in SQL.
+func mysqlDigest[T string | []byte](v T) []byte {
+	d := sha256.Sum256([]byte(v))
+	return d[:]
+}
+
+// migrations returns the MySQL driver's migrations.
+//
+// MySQL commits DDL statements implicitly, so each migration must be able to
+// be re-applied after it has failed part-way through. Migrations that create
+// tables use IF NOT EXISTS, and those that rebuild tables first remove any
+// tables and triggers left behind by a previous attempt.
+func (d mysqlDriver) migrations() []migration {
+	return []migration{
+		{
+			Version: 1,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.occ() + ` (
+					handler  VARBINARY(255) NOT NULL,
+					resource VARBINARY(255) NOT NULL,
+					version  VARBINARY(255) NOT NULL,
+
+					PRIMARY KEY (handler, resource)
+				) ENGINE=InnoDB`,
+			},
+		},
+		{
+			Version: 2,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.handlerVersionTable() + ` (
+					handler    VARBINARY(255) NOT NULL,
+					version    INT NOT NULL,
+					applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+					PRIMARY KEY (handler, version)
+				) ENGINE=InnoDB`,
+			},
+		},
+		{
+			// Migrate the OCC table to a layout that supports handler keys,
+			// resources and versions of any length.
+			//
+			// The primary key is formed from SHA-256 digests of the handler
+			// key and resource, as InnoDB limits the length of index keys.
+			// The table is rebuilt rather than altered so that the existing
+			// table is replaced atomically. Triggers copy any changes made to
+			// the existing table while it is being rebuilt, such that they are
+			// not lost.
+			//
+			// MySQL commits each DDL statement implicitly, so the migration
+			// may have been interrupted part-way through a previous attempt.
+			// The remnants of such an attempt are removed before the table is
+			// rebuilt. The statements that copy the rows refer only to the
+			// columns that are common to both layouts, so the migration can be
+			// re-applied even if the OCC table has already been replaced.
+			Version: 3,
+			Statements: []string{
+				`DROP TRIGGER IF EXISTS ` + d.migrationTrigger("insert"),
+				`DROP TRIGGER IF EXISTS ` + d.migrationTrigger("update"),
+				`DROP TRIGGER IF EXISTS ` + d.migrationTrigger("delete"),
+				`DROP TABLE IF EXISTS ` + d.migratingOCC() + `, ` + d.legacyOCC(),
+				`CREATE TABLE ` + d.migratingOCC() + ` (
+					handler_id  BINARY(32) NOT NULL,
+					resource_id BINARY(32) NOT NULL,
+					handler     LONGBLOB NOT NULL,
+					resource    LONGBLOB NOT NULL,
+					version     LONGBLOB NOT NULL,
+
+					PRIMARY KEY (handler_id, resource_id)
+				) ENGINE=InnoDB`,
+				`CREATE TRIGGER ` + d.migrationTrigger("insert") + `
+				AFTER INSERT ON ` + d.occ() + `
+				FOR EACH ROW
+					REPLACE INTO ` + d.migratingOCC() + ` (
+						handler_id,
+						resource_id,
+						handler,
+						resource,
+						version
+					) VALUES (
+						UNHEX(SHA2(NEW.handler, 256)),
+						UNHEX(SHA2(NEW.resource, 256)),
+						NEW.handler,
+						NEW.resource,
+						NEW.version
+					)`,
+				`CREATE TRIGGER ` + d.migrationTrigger("update") + `
+				AFTER UPDATE ON ` + d.occ() + `
+				FOR EACH ROW
+				BEGIN
+					DELETE FROM ` + d.migratingOCC() + `
+					WHERE handler_id = UNHEX(SHA2(OLD.handler, 256))
+					AND resource_id = UNHEX(SHA2(OLD.resource, 256));
+
+					REPLACE INTO ` + d.migratingOCC() + ` (
+						handler_id,
+						resource_id,
+						handler,
+						resource,
+						version
+					) VALUES (
+						UNHEX(SHA2(NEW.handler, 256)),
+						UNHEX(SHA2(NEW.resource, 256)),
+						NEW.handler,
+						NEW.resource,
+						NEW.version
+					);
+				END`,
+				`CREATE TRIGGER ` + d.migrationTrigger("delete") + `
+				AFTER DELETE ON ` + d.occ() + `
+				FOR EACH ROW
+					DELETE FROM ` + d.migratingOCC() + `
+					WHERE handler_id = UNHEX(SHA2(OLD.handler, 256))
+					AND resource_id = UNHEX(SHA2(OLD.resource, 256))`,
+				// Rows that have already been copied by a trigger are not
+				// overwritten, as the trigger's copy is at least as recent.
+				// The existing rows are read using a locking read so that
+				// they can not be changed until the copy is committed, which
+				// occurs implicitly when the tables are renamed.
+				`INSERT IGNORE INTO ` + d.migratingOCC() + ` (
+					handler_id,
+					resource_id,
+					handler,
+					resource,
+					version
+				)
+				SELECT
+					UNHEX(SHA2(handler, 256)),
+					UNHEX(SHA2(resource, 256)),
+					handler,
+					resource,
+					version
+				FROM ` + d.occ() + `
+				LOCK IN SHARE MODE`,
+				`RENAME TABLE
+					` + d.occ() + ` TO ` + d.legacyOCC() + `,
+					` + d.migratingOCC() + ` TO ` + d.occ(),
+				// The triggers are dropped along with the legacy table.
+				`DROP TABLE ` + d.legacyOCC(),
+			},
+		},
+		{
+			Version: 4,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.outboxTable() + ` (
+					id         BIGINT NOT NULL AUTO_INCREMENT,
+					handler_id BINARY(32) NOT NULL,
+					handler    LONGBLOB NOT NULL,
+					topic      LONGTEXT NOT NULL,
+					payload    LONGBLOB NOT NULL,
+
+					PRIMARY KEY (id),
+					INDEX (handler_id, id)
+				) ENGINE=InnoDB`,
+			},
+		},
+		{
+			// Migrate the handler version table to a layout that supports
+			// handler keys of any length, as per migration 3.
+			//
+			// Handler versions are only written while the schema lock is
+			// held, so unlike the OCC table, the table is not changed while
+			// it is being rebuilt. The migration is re-runnable in the same
+			// way as migration 3.
+			Version: 5,
+			Statements: []string{
+				`DROP TABLE IF EXISTS ` + d.migratingHandlerVersionTable() + `, ` + d.legacyHandlerVersionTable(),
+				`CREATE TABLE ` + d.migratingHandlerVersionTable() + ` (
+					handler_id BINARY(32) NOT NULL,
+					handler    LONGBLOB NOT NULL,
+					version    INT NOT NULL,
+					applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+					PRIMARY KEY (handler_id, version)
+				) ENGINE=InnoDB`,
+				`INSERT INTO ` + d.migratingHandlerVersionTable() + ` (
+					handler_id,
+					handler,
+					version,
+					applied_at
+				)
+				SELECT
+					UNHEX(SHA2(handler, 256)),
+					handler,
+					version,
+					applied_at
+				FROM ` + d.handlerVersionTable(),
+				`RENAME TABLE
+					` + d.handlerVersionTable() + ` TO ` + d.legacyHandlerVersionTable() + `,
+					` + d.migratingHandlerVersionTable() + ` TO ` + d.handlerVersionTable(),
+				`DROP TABLE ` + d.legacyHandlerVersionTable(),
+			},
+		},
+	}
+}
 
-			PRIMARY KEY (handler, resource)
-		) ENGINE=InnoDB;`,
+func (d mysqlDriver) lockSchema(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	// Lock names are limited to 64 characters, so the table name is hashed.
+	name := fmt.Sprintf("projectionkit:%016x", schemaLockID(d.occ()))
+
+	// GET_LOCK() is called with a short timeout in a loop so that cancelation
+	// of ctx is honored even if the underlying driver does not support it.
+	for {
+		row := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 1)`, name)
+
+		var ok sql.NullBool
+		if err := row.Scan(&ok); err != nil {
+			return nil, err
+		}
+
+		if !ok.Valid {
+			return nil, errors.New("GET_LOCK() returned NULL")
+		}
+
+		if ok.Bool {
+			break
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return func() error {
+		// The lock must be released even if ctx has been canceled, otherwise
+		// it remains held by the pooled connection.
+		_, err := conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, name)
+		return err
+	}, nil
+}
+
+func (d mysqlDriver) createSchemaVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS `+d.schemaVersionTable()+` (
+			version    INT NOT NULL PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		) ENGINE=InnoDB`,
+	)
+	return err
+}
+
+func (d mysqlDriver) tableExists(ctx context.Context, q rowQueryer, t tableName) (bool, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COUNT(*)
+		FROM information_schema.tables
+		WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE())
+		AND table_name = ?`,
+		t.Schema,
+		t.Table,
+	)
+
+	var count int
+	err := row.Scan(&count)
+	return count != 0, err
+}
+
+func (d mysqlDriver) querySchemaVersion(ctx context.Context, q rowQueryer) (int, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(MAX(version), 0)
+		FROM `+d.schemaVersionTable(),
+	)
+
+	var v int
+	err := row.Scan(&v)
+	return v, err
+}
+
+func (d mysqlDriver) storeSchemaVersion(ctx context.Context, tx *sql.Tx, v int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.schemaVersionTable()+` (
+			version
+		) VALUES (
+			?
+		)`,
+		v,
 	)
 	return err
 }
 
-func (mysqlDriver) DropSchema(ctx context.Context, db *sql.DB) error {
-	_, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS projection_occ`)
+func (d mysqlDriver) queryHandlerSchemaVersion(ctx context.Context, q rowQueryer, h string) (int, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(MAX(version), 0)
+		FROM `+d.handlerVersionTable()+`
+		WHERE handler_id = ?`,
+		mysqlDigest(h),
+	)
+
+	var v int
+	err := row.Scan(&v)
+	return v, err
+}
+
+func (d mysqlDriver) storeHandlerSchemaVersion(ctx context.Context, tx *sql.Tx, h string, v int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.handlerVersionTable()+` (
+			handler_id,
+			handler,
+			version
+		) VALUES (
+			?,
+			?,
+			?
+		)`,
+		mysqlDigest(h),
+		h,
+		v,
+	)
 	return err
 }
 
-func (mysqlDriver) StoreVersion(
+func (d mysqlDriver) StoreVersion(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -60,16 +480,22 @@ func (mysqlDriver) StoreVersion(
 ) error {
 	_, err := db.ExecContext(
 		ctx,
-		`INSERT INTO projection_occ (
+		`INSERT INTO `+d.occ()+` (
+			handler_id,
+			resource_id,
 			handler,
 			resource,
 			version
 		) VALUES (
+			?,
+			?,
 			?,
 			?,
 			?
 		) ON DUPLICATE KEY UPDATE
 			version = VALUES(version)`,
+		mysqlDigest(h),
+		mysqlDigest(r),
 		h,
 		r,
 		v,
@@ -89,16 +515,22 @@ func (d mysqlDriver) UpdateVersion(
 	if len(c) == 0 {
 		res, err := tx.ExecContext(
 			ctx,
-			`INSERT INTO projection_occ (
+			`INSERT INTO `+d.occ()+` (
+				handler_id,
+				resource_id,
 				handler,
 				resource,
 				version
 			) VALUES (
+				?,
+				?,
 				?,
 				?,
 				?
 			) ON DUPLICATE KEY UPDATE
-				handler = handler`, // do nothing
+				handler_id = handler_id`, // do nothing
+			mysqlDigest(h),
+			mysqlDigest(r),
 			h,
 			r,
 			n,
@@ -121,26 +553,26 @@ func (d mysqlDriver) UpdateVersion(
 		// If the "next" version is empty, we can delete the row entirely.
 		res, err = tx.ExecContext(
 			ctx,
-			`DELETE FROM projection_occ
-			WHERE handler = ?
-			AND resource = ?
+			`DELETE FROM `+d.occ()+`
+			WHERE handler_id = ?
+			AND resource_id = ?
 			AND version = ?`,
-			h,
-			r,
+			mysqlDigest(h),
+			mysqlDigest(r),
 			c,
 		)
 	} else {
 		// Otherwise we simply update the existing row.
 		res, err = tx.ExecContext(
 			ctx,
-			`UPDATE projection_occ SET
+			`UPDATE `+d.occ()+` SET
 				version = ?
-			WHERE handler = ?
-			AND resource = ?
+			WHERE handler_id = ?
+			AND resource_id = ?
 			AND version = ?`,
 			n,
-			h,
-			r,
+			mysqlDigest(h),
+			mysqlDigest(r),
 			c,
 		)
 	}
@@ -155,7 +587,7 @@ func (d mysqlDriver) UpdateVersion(
 	return count != 0, err
 }
 
-func (mysqlDriver) QueryVersion(
+func (d mysqlDriver) QueryVersion(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -165,11 +597,11 @@ func (mysqlDriver) QueryVersion(
 		ctx,
 		`SELECT
 			version
-		FROM projection_occ
-		WHERE handler = ?
-		AND resource = ?`,
-		h,
-		r,
+		FROM `+d.occ()+`
+		WHERE handler_id = ?
+		AND resource_id = ?`,
+		mysqlDigest(h),
+		mysqlDigest(r),
 	)
 
 	var v []byte
@@ -182,7 +614,55 @@ func (mysqlDriver) QueryVersion(
 	return v, err
 }
 
-func (mysqlDriver) DeleteResource(
+func (d mysqlDriver) QueryVersions(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	a []byte,
+	n int,
+) ([]resource.Item, error) {
+	// Resources are ordered by their digest, which is part of the primary
+	// key. An empty digest compares as less than any other.
+	after := []byte{}
+	if len(a) != 0 {
+		after = mysqlDigest(a)
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT
+			resource,
+			version
+		FROM `+d.occ()+`
+		WHERE handler_id = ?
+		AND resource_id > ?
+		ORDER BY resource_id
+		LIMIT ?`,
+		mysqlDigest(h),
+		after,
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []resource.Item
+
+	for rows.Next() {
+		var item resource.Item
+
+		if err := rows.Scan(&item.Resource, &item.Version); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (d mysqlDriver) DeleteResource(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -190,12 +670,179 @@ func (mysqlDriver) DeleteResource(
 ) error {
 	_, err := db.ExecContext(
 		ctx,
-		`DELETE FROM projection_occ
-		WHERE handler = ?
-		AND resource = ?`,
+		`DELETE FROM `+d.occ()+`
+		WHERE handler_id = ?
+		AND resource_id = ?`,
+		mysqlDigest(h),
+		mysqlDigest(r),
+	)
+
+	return err
+}
+
+func (d mysqlDriver) DeleteAllResources(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.occ()+`
+		WHERE handler_id = ?`,
+		mysqlDigest(h),
+	)
+
+	return err
+}
+
+func (d mysqlDriver) RenameHandlerKey(
+	ctx context.Context,
+	tx *sql.Tx,
+	o, n string,
+) (bool, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT
+			COUNT(*)
+		FROM `+d.occ()+`
+		WHERE handler_id = ?`,
+		mysqlDigest(n),
+	)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count != 0 {
+		return false, nil
+	}
+
+	for _, t := range []string{
+		d.occ(),
+		d.outboxTable(),
+		d.handlerVersionTable(),
+	} {
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE `+t+` SET
+				handler_id = ?,
+				handler = ?
+			WHERE handler_id = ?`,
+			mysqlDigest(n),
+			n,
+			mysqlDigest(o),
+		); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (d mysqlDriver) EnqueueOutboxRecord(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	topic string,
+	payload []byte,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.outboxTable()+` SET
+			handler_id = ?,
+			handler = ?,
+			topic = ?,
+			payload = ?`,
+		mysqlDigest(h),
 		h,
-		r,
+		topic,
+		payload,
+	)
+	return err
+}
+
+func (d mysqlDriver) ClaimOutboxRecords(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	n int,
+) ([]OutboxRecord, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT
+			id,
+			topic,
+			payload
+		FROM `+d.outboxTable()+`
+		WHERE handler_id = ?
+		ORDER BY id
+		LIMIT ?
+		FOR UPDATE`,
+		mysqlDigest(h),
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []OutboxRecord
+
+	for rows.Next() {
+		var rec OutboxRecord
+
+		if err := rows.Scan(&rec.ID, &rec.Topic, &rec.Payload); err != nil {
+			return nil, err
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, rows.Err()
+}
+
+func (d mysqlDriver) DeleteOutboxRecord(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	id int64,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.outboxTable()+`
+		WHERE handler_id = ?
+		AND id = ?`,
+		mysqlDigest(h),
+		id,
 	)
 
 	return err
 }
+
+// IsRetryableError returns true if err is a MySQL deadlock error (error number
+// 1213).
+func (d mysqlDriver) IsRetryableError(err error) bool {
+	// The MySQL driver does not expose the error number via a method, so we
+	// inspect the message instead, which has the form "Error 1213: ..." or
+	// "Error 1213 (40001): ..." depending on the driver version.
+	for ; err != nil; err = errors.Unwrap(err) {
+		if strings.HasPrefix(err.Error(), "Error 1213") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateTxOptions returns an error if opts specifies an isolation level
+// that is not supported by MySQL.
+func (d mysqlDriver) ValidateTxOptions(opts *sql.TxOptions) error {
+	return validateTxOptions(
+		opts,
+		sql.LevelReadUncommitted,
+		sql.LevelReadCommitted,
+		sql.LevelRepeatableRead,
+		sql.LevelSerializable,
+	)
+}
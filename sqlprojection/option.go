@@ -1,6 +1,62 @@
 package sqlprojection
 
+import (
+	"database/sql"
+	"log/slog"
+)
+
 // An Option configures the optional behavior of an SQL projection.
 type Option struct {
 	applyToCandidateSet func(*candidateSet)
+	applyToRepository   func(*ResourceRepository)
+	applyToAdaptor      func(*adaptor)
+}
+
+// WithLogger returns an Option that causes the projection to log its
+// operations to l, including the selection of the Driver.
+func WithLogger(l *slog.Logger) Option {
+	return Option{
+		applyToCandidateSet: func(s *candidateSet) {
+			s.logger = l
+		},
+		applyToAdaptor: func(a *adaptor) {
+			a.logger = l
+		},
+	}
+}
+
+// WithTxOptions returns an Option that sets the options used when beginning
+// transactions, such as the isolation level.
+//
+// The options are validated by the Driver when each transaction begins. They
+// are overridden by the handler's own options if it implements
+// TxOptionsMessageHandler.
+func WithTxOptions(opts *sql.TxOptions) Option {
+	return Option{
+		applyToRepository: func(rr *ResourceRepository) {
+			rr.txOptions = opts
+		},
+	}
+}
+
+// WithAutoCreateSchema returns an Option that determines whether the schema
+// is created automatically, before the first operation that accesses the OCC
+// table.
+//
+// If enabled is true the schema version is queried once for the lifetime of
+// the handler or ResourceRepository, and the schema is created, as per
+// CreateSchema(), only if it does not already exist. An existing schema is
+// never upgraded automatically; use MigrateSchema() instead. This includes an
+// OCC table that was created before schema versions were recorded, in which
+// case an error is returned.
+//
+// Automatic schema creation is disabled by default. Passing false disables it
+// even if an earlier option enabled it, which is useful in environments where
+// the application's database user lacks the privileges to create tables.
+func WithAutoCreateSchema(enabled bool) Option {
+	return Option{
+		applyToRepository: func(rr *ResourceRepository) {
+			rr.autoCreateSchema = enabled
+		},
+	}
 }
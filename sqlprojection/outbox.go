@@ -0,0 +1,159 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql"
+)
+
+// OutboxRecord is a message that a handler enqueues for publication, such as
+// an integration message that describes a change to its read model.
+type OutboxRecord struct {
+	// ID identifies the record within the handler's outbox. It is assigned by
+	// the database when the record is enqueued.
+	ID int64
+
+	// Topic is an application-defined value that describes the record, such
+	// as the type of message or its destination.
+	Topic string
+
+	// Payload is the content of the record.
+	Payload []byte
+}
+
+// EnqueueOutbox adds a record with the given topic and payload to the
+// handler's outbox.
+//
+// ctx MUST be the context passed to MessageHandler.HandleEvent() or
+// ResettableMessageHandler.Reset(), or a context derived from it, and tx MUST
+// be the transaction passed alongside it. Otherwise, EnqueueOutbox() panics.
+//
+// The record is only enqueued if the transaction is committed. Records are
+// published by RelayOutbox().
+func EnqueueOutbox(
+	ctx context.Context,
+	tx *sql.Tx,
+	topic string,
+	payload []byte,
+) error {
+	o, ok := ctx.Value(outboxKey{}).(outbox)
+	if !ok {
+		panic("sqlprojection.EnqueueOutbox() must be called with a context passed to HandleEvent() or Reset()")
+	}
+
+	return o.driver.EnqueueOutboxRecord(ctx, tx, o.key, topic, payload)
+}
+
+// RelayOutbox publishes the pending records in the outbox of the handler with
+// the given key.
+//
+// It is a convenience for NewResourceRepository(db, key, options...)
+// followed by a call to ResourceRepository.RelayOutbox().
+func RelayOutbox(
+	ctx context.Context,
+	db *sql.DB,
+	key string,
+	publish func(context.Context, OutboxRecord) error,
+	options ...Option,
+) error {
+	return NewResourceRepository(db, key, options...).RelayOutbox(ctx, publish)
+}
+
+// RelayOutbox publishes the pending records in the handler's outbox by calling
+// publish for each record.
+//
+// Records that were enqueued by the same transaction are published in the
+// order they were enqueued. Records that were enqueued by different
+// transactions may be published in any relative order, as record IDs are
+// assigned in the order records are inserted rather than the order in which
+// their transactions commit. On CockroachDB the IDs are not strictly
+// increasing across nodes. Consumers that require a global order must
+// establish it themselves, for example using the resource version.
+//
+// Records are claimed in batches. Each batch is claimed and acknowledged
+// within a single transaction, which remains open while publish is called and
+// prevents other relays from claiming the same records. A record is
+// acknowledged, and removed from the outbox, once publish returns nil.
+//
+// If publish returns an error, the records that have already been published
+// are acknowledged and the error is returned. The remaining records are
+// published by a future call to RelayOutbox().
+//
+// Records are published at least once. A record may be published again if
+// the transaction fails to commit, or is retried as per the repository's
+// RetryPolicy.
+func (rr *ResourceRepository) RelayOutbox(
+	ctx context.Context,
+	publish func(context.Context, OutboxRecord) error,
+) error {
+	ctx = rr.hookContext(ctx)
+
+	for {
+		n, err := rr.relayOutboxBatch(ctx, publish)
+		if err != nil || n < outboxBatchSize {
+			return err
+		}
+	}
+}
+
+// outboxBatchSize is the maximum number of records that are claimed by each
+// transaction performed by ResourceRepository.RelayOutbox().
+const outboxBatchSize = 100
+
+// relayOutboxBatch claims a batch of records from the handler's outbox and
+// publishes them.
+//
+// It returns the number of records that were published.
+func (rr *ResourceRepository) relayOutboxBatch(
+	ctx context.Context,
+	publish func(context.Context, OutboxRecord) error,
+) (int, error) {
+	var (
+		count      int
+		publishErr error
+	)
+
+	if _, err := rr.withTx(ctx, func(d Driver, tx *sql.Tx) (bool, error) {
+		// Discard the outcome of any previous attempt.
+		count, publishErr = 0, nil
+
+		records, err := d.ClaimOutboxRecords(ctx, tx, rr.key, outboxBatchSize)
+		if err != nil {
+			return false, err
+		}
+
+		for _, rec := range records {
+			if err := publish(ctx, rec); err != nil {
+				publishErr = err
+				break
+			}
+
+			if err := d.DeleteOutboxRecord(ctx, tx, rr.key, rec.ID); err != nil {
+				return false, err
+			}
+
+			count++
+		}
+
+		return count != 0, nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return count, publishErr
+}
+
+// outboxKey is the context key used to store the outbox that records are
+// added to by EnqueueOutbox().
+type outboxKey struct{}
+
+// outbox is the outbox of a specific handler.
+type outbox struct {
+	driver Driver
+	key    string
+}
+
+// withOutbox returns a context that causes EnqueueOutbox() to add records to
+// the outbox of the handler with the given key, using the driver d.
+func withOutbox(ctx context.Context, d Driver, key string) context.Context {
+	return context.WithValue(ctx, outboxKey{}, outbox{d, key})
+}
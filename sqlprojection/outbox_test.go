@@ -0,0 +1,256 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func EnqueueOutbox()", func() {
+	It("panics if the context was not passed to the handler", func() {
+		Expect(func() {
+			EnqueueOutbox(context.Background(), nil, "<topic>", nil) // nolint:errcheck
+		}).To(PanicWith("sqlprojection.EnqueueOutbox() must be called with a context passed to HandleEvent() or Reset()"))
+	})
+})
+
+var _ = Describe("func RelayOutbox()", func() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx       context.Context
+					cancel    context.CancelFunc
+					database  *sqltest.Database
+					db        *sql.DB
+					handler   *fixtures.MessageHandler
+					adaptor   dogma.ProjectionMessageHandler
+					published []OutboxRecord
+				)
+
+				// handle calls adaptor.HandleEvent() such that the handler
+				// enqueues an outbox record for each of the given payloads.
+				handle := func(r string, payloads ...string) error {
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						tx *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						for _, p := range payloads {
+							if err := EnqueueOutbox(ctx, tx, "<topic>", []byte(p)); err != nil {
+								return err
+							}
+						}
+						return nil
+					}
+
+					ok, err := adaptor.HandleEvent(
+						ctx,
+						[]byte(r),
+						nil,
+						[]byte("<version>"),
+						nil,
+						EventA1,
+					)
+					if err == nil && !ok {
+						err = errors.New("OCC conflict")
+					}
+					return err
+				}
+
+				publish := func(_ context.Context, rec OutboxRecord) error {
+					published = append(published, rec)
+					return nil
+				}
+
+				// payloads returns the payloads of the published records.
+				payloads := func() []string {
+					var p []string
+					for _, rec := range published {
+						p = append(p, string(rec.Payload))
+					}
+					return p
+				}
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					handler = &fixtures.MessageHandler{}
+					handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+						c.Identity("<projection>", "<key>")
+					}
+
+					adaptor = New(db, handler)
+					published = nil
+				})
+
+				AfterEach(func() {
+					err := DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("publishes the records enqueued by each transaction in order", func() {
+					err := handle("<resource-a>", "<payload-1>", "<payload-2>")
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = handle("<resource-b>", "<payload-3>")
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = RelayOutbox(ctx, db, "<key>", publish)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					Expect(payloads()).To(ConsistOf("<payload-1>", "<payload-2>", "<payload-3>"))
+					Expect(published[0].Topic).To(Equal("<topic>"))
+
+					// Records from different transactions may be interleaved.
+					var same []OutboxRecord
+					for _, rec := range published {
+						if string(rec.Payload) != "<payload-3>" {
+							same = append(same, rec)
+						}
+					}
+
+					Expect(string(same[0].Payload)).To(Equal("<payload-1>"))
+					Expect(string(same[1].Payload)).To(Equal("<payload-2>"))
+					Expect(same[0].ID).To(BeNumerically("<", same[1].ID))
+				})
+
+				It("removes records once they are published", func() {
+					err := handle("<resource>", "<payload>")
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = RelayOutbox(ctx, db, "<key>", publish)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					published = nil
+
+					err = RelayOutbox(ctx, db, "<key>", publish)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(published).To(BeEmpty())
+				})
+
+				It("publishes records in several batches", func() {
+					var p []string
+					for i := 0; i < 250; i++ {
+						p = append(p, strconv.Itoa(i))
+					}
+
+					err := handle("<resource>", p...)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = RelayOutbox(ctx, db, "<key>", publish)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(payloads()).To(Equal(p))
+				})
+
+				It("does not enqueue records if the handler fails", func() {
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						tx *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						if err := EnqueueOutbox(ctx, tx, "<topic>", []byte("<payload>")); err != nil {
+							return err
+						}
+						return errors.New("<error>")
+					}
+
+					_, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).To(MatchError("<error>"))
+
+					err = RelayOutbox(ctx, db, "<key>", publish)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(published).To(BeEmpty())
+				})
+
+				It("retains the records that are not published if the publisher fails", func() {
+					err := handle("<resource>", "<payload-1>", "<payload-2>", "<payload-3>")
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = RelayOutbox(
+						ctx,
+						db,
+						"<key>",
+						func(ctx context.Context, rec OutboxRecord) error {
+							if string(rec.Payload) == "<payload-2>" {
+								return errors.New("<error>")
+							}
+							return publish(ctx, rec)
+						},
+					)
+					Expect(err).To(MatchError("<error>"))
+					Expect(payloads()).To(Equal([]string{"<payload-1>"}))
+
+					published = nil
+
+					err = RelayOutbox(ctx, db, "<key>", publish)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(payloads()).To(Equal([]string{"<payload-2>", "<payload-3>"}))
+				})
+
+				It("only publishes the records of the given handler", func() {
+					err := handle("<resource>", "<payload>")
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = RelayOutbox(ctx, db, "<other>", publish)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(published).To(BeEmpty())
+				})
+
+				It("moves records to the new key when the handler key is renamed", func() {
+					err := handle("<resource>", "<payload>")
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = NewResourceRepository(db, "<key>").RenameHandlerKey(ctx, "<key>", "<renamed>")
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = RelayOutbox(ctx, db, "<renamed>", publish)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(payloads()).To(Equal([]string{"<payload>"}))
+				})
+			},
+		)
+	}
+})
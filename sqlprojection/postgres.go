@@ -3,6 +3,10 @@ package sqlprojection
 import (
 	"context"
 	"database/sql"
+	"errors"
+
+	"github.com/dogmatiq/projectionkit/internal/pgnotify"
+	"github.com/dogmatiq/projectionkit/resource"
 )
 
 // PostgresDriver is a Driver for PostgreSQL.
@@ -11,9 +15,39 @@ import (
 // PostgreSQL compatible databases and $1-style placeholders.
 var PostgresDriver Driver = postgresDriver{}
 
-type postgresDriver struct{}
+type postgresDriver struct {
+	name          tableName
+	notifications bool
+}
+
+func (d postgresDriver) withTableName(n tableName) Driver {
+	d.name = n
+	return d
+}
+
+func (d postgresDriver) withNotifications(enabled bool) Driver {
+	d.notifications = enabled
+	return d
+}
+
+// table returns the name of the table used to store resource versions.
+func (d postgresDriver) table() tableName {
+	if d.name.Table == "" {
+		return tableName{"projection", "occ"}
+	}
+	return d.name
+}
+
+// occ returns the quoted name of the table used to store resource versions.
+func (d postgresDriver) occ() string {
+	return d.table().qualified(quoteANSI)
+}
 
-func (postgresDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
+func (d postgresDriver) Dialect() Dialect {
+	return PostgresDialect
+}
+
+func (d postgresDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
 	// Verify that we're using PostgreSQL and that $1-style placeholders are
 	// supported.
 	_, err := db.ExecContext(
@@ -24,24 +58,135 @@ func (postgresDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
 	return err
 }
 
-func (postgresDriver) CreateSchema(ctx context.Context, db *sql.DB) error {
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
+func (d postgresDriver) CreateSchema(ctx context.Context, db *sql.DB) error {
+	return d.MigrateSchema(ctx, db)
+}
+
+func (d postgresDriver) DropSchema(ctx context.Context, db *sql.DB) error {
+	if d.name.Table != "" {
+		// The schema may be shared with other tables, so only drop the tables
+		// themselves when a custom name is used.
+		_, err := db.ExecContext(
+			ctx,
+			`DROP TABLE IF EXISTS `+d.occ()+`, `+d.schemaVersionTable()+`, `+d.handlerVersionTable()+`, `+d.outboxTable(),
+		)
 		return err
 	}
-	defer tx.Rollback() // nolint:errcheck
 
-	_, err = tx.ExecContext(ctx, `CREATE SCHEMA IF NOT EXISTS projection`)
+	_, err := db.ExecContext(ctx, `DROP SCHEMA IF EXISTS projection CASCADE`)
+	return err
+}
+
+func (d postgresDriver) MigrateSchema(ctx context.Context, db *sql.DB) error {
+	return migrateSchema(ctx, db, d)
+}
+
+func (d postgresDriver) MigrateHandlerSchema(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	migrations []Migration,
+) error {
+	return migrateHandlerSchema(ctx, db, d, h, migrations)
+}
+
+func (d postgresDriver) SchemaVersion(ctx context.Context, db *sql.DB) (int, int, error) {
+	return schemaVersion(ctx, db, d)
+}
+
+// schemaVersionTable returns the quoted name of the table used to record
+// which migrations have been applied.
+func (d postgresDriver) schemaVersionTable() string {
+	return d.table().withSuffix("_schema_version").qualified(quoteANSI)
+}
+
+// handlerVersionTable returns the quoted name of the table used to record
+// which handler migrations have been applied.
+func (d postgresDriver) handlerVersionTable() string {
+	return d.table().withSuffix("_handler_version").qualified(quoteANSI)
+}
+
+// outboxTable returns the quoted name of the table used to store outbox
+// records.
+func (d postgresDriver) outboxTable() string {
+	return d.table().withSuffix("_outbox").qualified(quoteANSI)
+}
+
+func (d postgresDriver) migrations() []migration {
+	return []migration{
+		{
+			Version: 1,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.occ() + ` (
+					handler  BYTEA NOT NULL,
+					resource BYTEA NOT NULL,
+					version  BYTEA NOT NULL,
+
+					PRIMARY KEY (handler, resource)
+				)`,
+			},
+		},
+		{
+			Version: 2,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.handlerVersionTable() + ` (
+					handler    BYTEA NOT NULL,
+					version    INTEGER NOT NULL,
+					applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+					PRIMARY KEY (handler, version)
+				)`,
+			},
+		},
+		{
+			Version: 3,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.outboxTable() + ` (
+					id      BIGSERIAL NOT NULL,
+					handler BYTEA NOT NULL,
+					topic   TEXT NOT NULL,
+					payload BYTEA NOT NULL,
+
+					PRIMARY KEY (handler, id)
+				)`,
+			},
+		},
+	}
+}
+
+func (d postgresDriver) lockSchema(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	// Advisory locks are identified by a signed 64-bit integer.
+	id := int64(schemaLockID(d.occ()))
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, id); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		// The lock must be released even if ctx has been canceled, otherwise
+		// it remains held by the pooled connection.
+		_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, id)
+		return err
+	}, nil
+}
+
+func (d postgresDriver) createSchemaVersionTable(ctx context.Context, conn *sql.Conn) error {
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback() // nolint:errcheck
 
-	_, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS projection.occ (
-			handler  BYTEA NOT NULL,
-			resource BYTEA NOT NULL,
-			version  BYTEA NOT NULL,
+	if schema := d.table().Schema; schema != "" {
+		_, err = tx.ExecContext(ctx, `CREATE SCHEMA IF NOT EXISTS `+quoteANSI(schema))
+		if err != nil {
+			return err
+		}
+	}
 
-			PRIMARY KEY (handler, resource)
+	_, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+d.schemaVersionTable()+` (
+			version    INTEGER NOT NULL PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
 		)`,
 	)
 	if err != nil {
@@ -51,12 +196,76 @@ func (postgresDriver) CreateSchema(ctx context.Context, db *sql.DB) error {
 	return tx.Commit()
 }
 
-func (postgresDriver) DropSchema(ctx context.Context, db *sql.DB) error {
-	_, err := db.ExecContext(ctx, `DROP SCHEMA IF EXISTS projection CASCADE`)
+func (d postgresDriver) tableExists(ctx context.Context, q rowQueryer, t tableName) (bool, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT to_regclass($1) IS NOT NULL`,
+		t.qualified(quoteANSI),
+	)
+
+	var ok bool
+	err := row.Scan(&ok)
+	return ok, err
+}
+
+func (d postgresDriver) querySchemaVersion(ctx context.Context, q rowQueryer) (int, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(MAX(version), 0)
+		FROM `+d.schemaVersionTable(),
+	)
+
+	var v int
+	err := row.Scan(&v)
+	return v, err
+}
+
+func (d postgresDriver) storeSchemaVersion(ctx context.Context, tx *sql.Tx, v int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.schemaVersionTable()+` (
+			version
+		) VALUES (
+			$1
+		)`,
+		v,
+	)
+	return err
+}
+
+func (d postgresDriver) queryHandlerSchemaVersion(ctx context.Context, q rowQueryer, h string) (int, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(MAX(version), 0)
+		FROM `+d.handlerVersionTable()+`
+		WHERE handler = $1`,
+		h,
+	)
+
+	var v int
+	err := row.Scan(&v)
+	return v, err
+}
+
+func (d postgresDriver) storeHandlerSchemaVersion(ctx context.Context, tx *sql.Tx, h string, v int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.handlerVersionTable()+` (
+			handler,
+			version
+		) VALUES (
+			$1,
+			$2
+		)`,
+		h,
+		v,
+	)
 	return err
 }
 
-func (postgresDriver) StoreVersion(
+func (d postgresDriver) StoreVersion(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -64,7 +273,7 @@ func (postgresDriver) StoreVersion(
 ) error {
 	_, err := db.ExecContext(
 		ctx,
-		`INSERT INTO projection.occ (
+		`INSERT INTO `+d.occ()+` (
 			handler,
 			resource,
 			version
@@ -78,8 +287,11 @@ func (postgresDriver) StoreVersion(
 		r,
 		v,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return d.notify(ctx, db, h, r)
 }
 
 func (d postgresDriver) UpdateVersion(
@@ -93,7 +305,7 @@ func (d postgresDriver) UpdateVersion(
 	if len(c) == 0 {
 		res, err := tx.ExecContext(
 			ctx,
-			`INSERT INTO projection.occ (
+			`INSERT INTO `+d.occ()+` (
 				handler,
 				resource,
 				version
@@ -111,8 +323,12 @@ func (d postgresDriver) UpdateVersion(
 		}
 
 		// The affected rows will be exactly 1 if the row was inserted.
-		n, err := res.RowsAffected()
-		return n == 1, err
+		count, err := res.RowsAffected()
+		if count != 1 || err != nil {
+			return false, err
+		}
+
+		return true, d.notify(ctx, tx, h, r)
 	}
 
 	var (
@@ -124,7 +340,7 @@ func (d postgresDriver) UpdateVersion(
 		// If the "next" version is empty, we can delete the row entirely.
 		res, err = tx.ExecContext(
 			ctx,
-			`DELETE FROM projection.occ
+			`DELETE FROM `+d.occ()+`
 			WHERE handler = $1
 			AND resource = $2
 			AND version = $3`,
@@ -136,7 +352,7 @@ func (d postgresDriver) UpdateVersion(
 		// Otherwise we simply update the existing row.
 		res, err = tx.ExecContext(
 			ctx,
-			`UPDATE projection.occ SET
+			`UPDATE `+d.occ()+` SET
 				version = $1
 			WHERE handler = $2
 			AND resource = $3
@@ -155,10 +371,134 @@ func (d postgresDriver) UpdateVersion(
 	}
 
 	count, err := res.RowsAffected()
-	return count != 0, err
+	if count == 0 || err != nil {
+		return false, err
+	}
+
+	return true, d.notify(ctx, tx, h, r)
+}
+
+// notify sends a notification that the version of resource r for the handler
+// h has been updated, if notifications are enabled.
+//
+// When called within a transaction, the notification is only delivered if the
+// transaction is committed.
+func (d postgresDriver) notify(
+	ctx context.Context,
+	db interface {
+		ExecContext(context.Context, string, ...any) (sql.Result, error)
+	},
+	h string,
+	r []byte,
+) error {
+	if !d.notifications {
+		return nil
+	}
+
+	_, err := db.ExecContext(
+		ctx,
+		`SELECT pg_notify($1, $2)`,
+		pgnotify.Channel(d.occ()),
+		pgnotify.Payload(h, r),
+	)
+	return err
+}
+
+// waitForNotification blocks until reached returns true, calling it whenever a
+// notification is received for resource r of the handler h.
+//
+// Notifications can only be received using the "pgx" driver. Other drivers
+// fall back to polling.
+func (d postgresDriver) waitForNotification(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	r []byte,
+	reached func() (bool, error),
+) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	supported := false
+	if err := conn.Raw(func(c any) error {
+		_, supported = pgxNotificationWaiter(c)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if !supported {
+		conn.Close()
+		return pollForVersion(ctx, reached)
+	}
+
+	channel := quoteANSI(pgnotify.Channel(d.occ()))
+	payload := pgnotify.Payload(h, r)
+
+	if _, err := conn.ExecContext(ctx, `LISTEN `+channel); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `UNLISTEN `+channel) // nolint:errcheck
+
+	return conn.Raw(func(c any) error {
+		wait, _ := pgxNotificationWaiter(c)
+
+		for {
+			// The version is queried after LISTEN is executed, so that no
+			// update can be missed.
+			ok, err := reached()
+			if ok || err != nil {
+				return err
+			}
+
+			if err := waitForPayload(ctx, wait, payload); err != nil {
+				return err
+			}
+		}
+	})
 }
 
-func (postgresDriver) QueryVersion(
+// waitForPayload calls wait until it returns a notification with the given
+// payload, or until waitMaxBackoff has elapsed.
+//
+// The same deadline applies to every notification received, so that the
+// caller queries the version again at least once per waitMaxBackoff even if
+// notifications about other resources are received continuously.
+func waitForPayload(
+	ctx context.Context,
+	wait func(context.Context) (string, error),
+	payload string,
+) error {
+	waitCtx, cancel := context.WithTimeout(ctx, waitMaxBackoff)
+	defer cancel()
+
+	for {
+		p, err := wait(waitCtx)
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if waitCtx.Err() != nil {
+			// Query the version again in case a notification was missed, for
+			// example because the version was changed by another means.
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if p == payload {
+			return nil
+		}
+	}
+}
+
+func (d postgresDriver) QueryVersion(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -168,7 +508,7 @@ func (postgresDriver) QueryVersion(
 		ctx,
 		`SELECT
 			version
-		FROM projection.occ
+		FROM `+d.occ()+`
 		WHERE handler = $1
 		AND resource = $2`,
 		h,
@@ -185,7 +525,54 @@ func (postgresDriver) QueryVersion(
 	return v, err
 }
 
-func (postgresDriver) DeleteResource(
+func (d postgresDriver) QueryVersions(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	a []byte,
+	n int,
+) ([]resource.Item, error) {
+	if a == nil {
+		// A nil slice is treated as NULL, which never compares as less than
+		// any resource.
+		a = []byte{}
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT
+			resource,
+			version
+		FROM `+d.occ()+`
+		WHERE handler = $1
+		AND resource > $2
+		ORDER BY resource
+		LIMIT $3`,
+		h,
+		a,
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []resource.Item
+
+	for rows.Next() {
+		var item resource.Item
+
+		if err := rows.Scan(&item.Resource, &item.Version); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (d postgresDriver) DeleteResource(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -193,7 +580,7 @@ func (postgresDriver) DeleteResource(
 ) error {
 	_, err := db.ExecContext(
 		ctx,
-		`DELETE FROM projection.occ
+		`DELETE FROM `+d.occ()+`
 		WHERE handler = $1
 		AND resource = $2`,
 		h,
@@ -202,3 +589,190 @@ func (postgresDriver) DeleteResource(
 
 	return err
 }
+
+func (d postgresDriver) DeleteAllResources(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.occ()+`
+		WHERE handler = $1`,
+		h,
+	)
+
+	return err
+}
+
+func (d postgresDriver) RenameHandlerKey(
+	ctx context.Context,
+	tx *sql.Tx,
+	o, n string,
+) (bool, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT
+			COUNT(*)
+		FROM `+d.occ()+`
+		WHERE handler = $1`,
+		n,
+	)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count != 0 {
+		return false, nil
+	}
+
+	for _, t := range []string{
+		d.occ(),
+		d.outboxTable(),
+		d.handlerVersionTable(),
+	} {
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE `+t+` SET
+				handler = $1
+			WHERE handler = $2`,
+			n,
+			o,
+		); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (d postgresDriver) EnqueueOutboxRecord(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	topic string,
+	payload []byte,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.outboxTable()+` (
+			handler,
+			topic,
+			payload
+		) VALUES (
+			$1,
+			$2,
+			$3
+		)`,
+		h,
+		topic,
+		payload,
+	)
+	return err
+}
+
+func (d postgresDriver) ClaimOutboxRecords(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	n int,
+) ([]OutboxRecord, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT
+			id,
+			topic,
+			payload
+		FROM `+d.outboxTable()+`
+		WHERE handler = $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE`,
+		h,
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []OutboxRecord
+
+	for rows.Next() {
+		var rec OutboxRecord
+
+		if err := rows.Scan(&rec.ID, &rec.Topic, &rec.Payload); err != nil {
+			return nil, err
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, rows.Err()
+}
+
+func (d postgresDriver) DeleteOutboxRecord(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	id int64,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.outboxTable()+`
+		WHERE handler = $1
+		AND id = $2`,
+		h,
+		id,
+	)
+
+	return err
+}
+
+// IsRetryableError returns true if err is a PostgreSQL serialization failure
+// (SQLSTATE 40001) or deadlock (SQLSTATE 40P01).
+func (d postgresDriver) IsRetryableError(err error) bool {
+	switch postgresErrorCode(err) {
+	case "40001", "40P01":
+		return true
+	default:
+		return false
+	}
+}
+
+// postgresErrorCode returns the SQLSTATE code of a PostgreSQL error, or an
+// empty string if err is not a PostgreSQL error.
+//
+// It supports the error types used by both the "pgx" and "pq" drivers without
+// depending on either.
+func postgresErrorCode(err error) string {
+	// pgconn.PgError, used by the "pgx" driver.
+	var pgx interface{ SQLState() string }
+	if errors.As(err, &pgx) {
+		return pgx.SQLState()
+	}
+
+	// pq.Error, used by the "postgres" driver.
+	var pq interface{ Get(k byte) string }
+	if errors.As(err, &pq) {
+		return pq.Get('C')
+	}
+
+	return ""
+}
+
+// ValidateTxOptions returns an error if opts specifies an isolation level
+// that is not supported by PostgreSQL.
+//
+// PostgreSQL accepts READ UNCOMMITTED, but treats it as READ COMMITTED.
+func (d postgresDriver) ValidateTxOptions(opts *sql.TxOptions) error {
+	return validateTxOptions(
+		opts,
+		sql.LevelReadUncommitted,
+		sql.LevelReadCommitted,
+		sql.LevelRepeatableRead,
+		sql.LevelSerializable,
+	)
+}
@@ -0,0 +1,343 @@
+package sqlprojection
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+)
+
+// QueryEvent describes an SQL statement that was executed on behalf of a
+// projection.
+type QueryEvent struct {
+	// HandlerKey is the identity key of the handler on whose behalf the
+	// statement was executed.
+	HandlerKey string
+
+	// Query is the text of the statement.
+	Query string
+
+	// Args are the arguments passed to the statement, after conversion by the
+	// database/sql package.
+	Args []any
+
+	// Duration is the time taken to execute the statement. For queries it does
+	// not include the time taken to read the resulting rows.
+	Duration time.Duration
+
+	// Err is the error returned by the database/sql driver, if any.
+	Err error
+}
+
+// QueryHook is a function that is called after an SQL statement is executed
+// on behalf of a projection.
+type QueryHook func(context.Context, QueryEvent)
+
+// WithQueryHook returns an Option that calls hook after each SQL statement is
+// executed on behalf of the projection, including those executed by the
+// Driver and those executed by the handler.
+//
+// The *sql.Tx and *sql.DB values passed to handlers are concrete types that
+// can not be wrapped, so statements are intercepted at the database/sql
+// driver level instead. The database MUST be opened using a connector returned
+// by InterceptConnector(), otherwise hook is never called.
+//
+// Statements are attributed to the projection by way of their context. Only
+// statements executed using the context passed to the handler, or a context
+// derived from it, are reported.
+func WithQueryHook(hook QueryHook) Option {
+	return Option{
+		applyToRepository: func(rr *ResourceRepository) {
+			rr.queryHook = hook
+		},
+	}
+}
+
+// InterceptConnector returns a connector that opens connections using c, and
+// reports the statements executed on them to the QueryHook configured by
+// WithQueryHook().
+//
+// The returned connector is intended to be passed to sql.OpenDB(). It does
+// not otherwise alter the behavior of the connections.
+func InterceptConnector(c driver.Connector) driver.Connector {
+	return hookConnector{c}
+}
+
+// queryHookKey is the context key used to store the QueryHook that is called
+// for statements executed with that context.
+type queryHookKey struct{}
+
+// queryHook is a QueryHook along with the key of the handler that it belongs
+// to.
+type queryHook struct {
+	key  string
+	hook QueryHook
+}
+
+// hookContext returns a context that causes statements executed using
+// connections from InterceptConnector() to be reported to rr's QueryHook.
+func (rr *ResourceRepository) hookContext(ctx context.Context) context.Context {
+	if rr.queryHook == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, queryHookKey{}, queryHook{rr.key, rr.queryHook})
+}
+
+// observe calls fn, which executes the statement q, and reports the outcome
+// to the QueryHook associated with ctx, if any.
+func observe(
+	ctx context.Context,
+	q string,
+	args []driver.NamedValue,
+	fn func() error,
+) error {
+	h, ok := ctx.Value(queryHookKey{}).(queryHook)
+	if !ok {
+		return fn()
+	}
+
+	start := time.Now()
+	err := fn()
+	duration := time.Since(start)
+
+	// The statement is executed again by the database/sql package, using a
+	// prepared statement.
+	if errors.Is(err, driver.ErrSkip) {
+		return err
+	}
+
+	values := make([]any, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+
+	h.hook(ctx, QueryEvent{
+		HandlerKey: h.key,
+		Query:      q,
+		Args:       values,
+		Duration:   duration,
+		Err:        err,
+	})
+
+	return err
+}
+
+// hookConnector is a driver.Connector that returns connections that report
+// statements to a QueryHook.
+type hookConnector struct {
+	driver.Connector
+}
+
+func (c hookConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookConn{conn}, nil
+}
+
+func (c hookConnector) Close() error {
+	if c, ok := c.Connector.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// hookConn is a driver.Conn that reports statements to a QueryHook.
+//
+// It implements each of the optional driver interfaces, deferring to the
+// underlying connection where it implements them, and otherwise behaving as
+// the database/sql package does in their absence.
+type hookConn struct {
+	driver.Conn
+}
+
+func (c *hookConn) Prepare(q string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), q)
+}
+
+func (c *hookConn) PrepareContext(ctx context.Context, q string) (driver.Stmt, error) {
+	var (
+		s   driver.Stmt
+		err error
+	)
+
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, q)
+	} else {
+		s, err = c.Conn.Prepare(q)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &hookStmt{s, c.Conn, q}, nil
+}
+
+func (c *hookConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	if opts.Isolation != 0 {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+
+	return c.Conn.Begin() // nolint:staticcheck
+}
+
+func (c *hookConn) ExecContext(
+	ctx context.Context,
+	q string,
+	args []driver.NamedValue,
+) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	var res driver.Result
+	err := observe(ctx, q, args, func() (err error) {
+		res, err = e.ExecContext(ctx, q, args)
+		return err
+	})
+
+	return res, err
+}
+
+func (c *hookConn) QueryContext(
+	ctx context.Context,
+	q string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	qr, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	var rows driver.Rows
+	err := observe(ctx, q, args, func() (err error) {
+		rows, err = qr.QueryContext(ctx, q, args)
+		return err
+	})
+
+	return rows, err
+}
+
+func (c *hookConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *hookConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *hookConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *hookConn) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+// hookStmt is a driver.Stmt that reports its executions to a QueryHook.
+type hookStmt struct {
+	driver.Stmt
+	conn  driver.Conn
+	query string
+}
+
+func (s *hookStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+
+	err := observe(ctx, s.query, args, func() (err error) {
+		if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+			res, err = e.ExecContext(ctx, args)
+			return err
+		}
+
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return err
+		}
+
+		res, err = s.Stmt.Exec(values) // nolint:staticcheck
+		return err
+	})
+
+	return res, err
+}
+
+func (s *hookStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+
+	err := observe(ctx, s.query, args, func() (err error) {
+		if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			rows, err = q.QueryContext(ctx, args)
+			return err
+		}
+
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return err
+		}
+
+		rows, err = s.Stmt.Query(values) // nolint:staticcheck
+		return err
+	})
+
+	return rows, err
+}
+
+// CheckNamedValue defers to the underlying statement, or otherwise to the
+// underlying connection, as the database/sql package only consults the
+// connection when the statement does not implement driver.NamedValueChecker.
+func (s *hookStmt) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	if n, ok := s.conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+func (s *hookStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if c, ok := s.Stmt.(driver.ColumnConverter); ok { // nolint:staticcheck
+		return c.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+// namedValuesToValues converts args to the values accepted by the deprecated
+// driver.Stmt methods, which do not support named arguments.
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = a.Value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,206 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func WithQueryHook()", func() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+					hooked   *sql.DB
+					handler  *fixtures.MessageHandler
+					adaptor  dogma.ProjectionMessageHandler
+
+					m      sync.Mutex
+					events []QueryEvent
+				)
+
+				hook := func(_ context.Context, e QueryEvent) {
+					m.Lock()
+					defer m.Unlock()
+					events = append(events, e)
+				}
+
+				// queries returns the events for statements that contain the
+				// given text.
+				queries := func(text string) []QueryEvent {
+					m.Lock()
+					defer m.Unlock()
+
+					var matches []QueryEvent
+					for _, e := range events {
+						if strings.Contains(e.Query, text) {
+							matches = append(matches, e)
+						}
+					}
+					return matches
+				}
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					_, err = db.ExecContext(ctx, `CREATE TABLE query_hook_test (id INTEGER NOT NULL)`)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					hooked = sql.OpenDB(
+						InterceptConnector(
+							dsnConnector{
+								dsn:    database.DataSource.DSN(),
+								driver: db.Driver(),
+							},
+						),
+					)
+
+					events = nil
+
+					handler = &fixtures.MessageHandler{}
+					handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+						c.Identity("<projection>", "<key>")
+					}
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						tx *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						_, err := tx.ExecContext(ctx, `INSERT INTO query_hook_test (id) VALUES (1)`)
+						return err
+					}
+
+					adaptor = New(hooked, handler, WithQueryHook(hook))
+				})
+
+				AfterEach(func() {
+					err := hooked.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					_, err = db.ExecContext(ctx, `DROP TABLE IF EXISTS query_hook_test`)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("reports the statements executed by the handler", func() {
+					ok, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeTrue())
+
+					matches := queries("INSERT INTO query_hook_test")
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].HandlerKey).To(Equal("<key>"))
+					Expect(matches[0].Duration).To(BeNumerically(">", 0))
+					Expect(matches[0].Err).ShouldNot(HaveOccurred())
+				})
+
+				It("reports the statements executed by the driver", func() {
+					_, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+					Expect(err).ShouldNot(HaveOccurred())
+
+					Expect(events).NotTo(BeEmpty())
+
+					for _, e := range events {
+						Expect(e.HandlerKey).To(Equal("<key>"))
+					}
+
+					Expect(events[len(events)-1].Args).To(ContainElement(BeEquivalentTo("<resource>")))
+				})
+
+				It("reports statements that fail", func() {
+					handler.HandleEventFunc = func(
+						ctx context.Context,
+						tx *sql.Tx,
+						_ dogma.ProjectionEventScope,
+						_ dogma.Event,
+					) error {
+						_, err := tx.ExecContext(ctx, `SELECT * FROM query_hook_nonexistent`)
+						return err
+					}
+
+					_, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).Should(HaveOccurred())
+
+					matches := queries("query_hook_nonexistent")
+					Expect(matches).NotTo(BeEmpty())
+					Expect(matches[0].Err).Should(HaveOccurred())
+				})
+
+				It("does not report statements executed with an unrelated context", func() {
+					_, err := hooked.ExecContext(ctx, `INSERT INTO query_hook_test (id) VALUES (1)`)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(events).To(BeEmpty())
+				})
+			},
+		)
+	}
+})
+
+// dsnConnector is a driver.Connector that opens connections using a DSN.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
@@ -3,16 +3,27 @@ package sqlprojection
 import (
 	"context"
 	"database/sql"
+	"iter"
 
+	"github.com/dogmatiq/cosyne"
 	"github.com/dogmatiq/projectionkit/resource"
 )
 
 // ResourceRepository is an implementation of resource.Repository that stores
 // resources versions in an SQL database.
 type ResourceRepository struct {
-	db  *sql.DB
-	key string
-	cs  candidateSet
+	db    *sql.DB
+	key   string
+	cs    candidateSet
+	retry RetryPolicy
+	reset func(context.Context, *sql.Tx) error
+
+	txOptions *sql.TxOptions
+	queryHook QueryHook
+
+	autoCreateSchema bool
+	schemaM          cosyne.Mutex
+	schemaEnsured    uint32
 }
 
 // NewResourceRepository returns a new [ResourceRepository] that uses db to
@@ -23,19 +34,33 @@ func NewResourceRepository(
 	options ...Option,
 ) *ResourceRepository {
 	rr := &ResourceRepository{
-		db:  db,
-		key: key,
+		db:    db,
+		key:   key,
+		retry: DefaultRetryPolicy,
 	}
 
 	rr.cs.init(db, options)
 
+	for _, opt := range options {
+		if opt.applyToRepository != nil {
+			opt.applyToRepository(rr)
+		}
+	}
+
 	return rr
 }
 
-var _ resource.Repository = (*ResourceRepository)(nil)
+var (
+	_ resource.ListableRepository   = (*ResourceRepository)(nil)
+	_ resource.ResettableRepository = (*ResourceRepository)(nil)
+	_ resource.RenamableRepository  = (*ResourceRepository)(nil)
+	_ resource.CheckableRepository  = (*ResourceRepository)(nil)
+)
 
 // ResourceVersion returns the version of the resource r.
 func (rr *ResourceRepository) ResourceVersion(ctx context.Context, r []byte) ([]byte, error) {
+	ctx = rr.hookContext(ctx)
+
 	var v []byte
 
 	return v, rr.withDriver(ctx, func(d Driver) error {
@@ -48,6 +73,8 @@ func (rr *ResourceRepository) ResourceVersion(ctx context.Context, r []byte) ([]
 // StoreResourceVersion sets the version of the resource r to v without checking
 // the current version.
 func (rr *ResourceRepository) StoreResourceVersion(ctx context.Context, r, v []byte) error {
+	ctx = rr.hookContext(ctx)
+
 	return rr.withDriver(ctx, func(d Driver) error {
 		if len(v) == 0 {
 			return d.DeleteResource(ctx, rr.db, rr.key, r)
@@ -64,6 +91,8 @@ func (rr *ResourceRepository) UpdateResourceVersion(
 	ctx context.Context,
 	r, c, n []byte,
 ) (ok bool, err error) {
+	ctx = rr.hookContext(ctx)
+
 	return rr.withTx(ctx, func(d Driver, tx *sql.Tx) (bool, error) {
 		return d.UpdateVersion(ctx, tx, rr.key, r, c, n)
 	})
@@ -73,35 +102,156 @@ func (rr *ResourceRepository) UpdateResourceVersion(
 // performs a user-defined operation within the same transaction.
 //
 // If c is not the current version of r, it returns false and no update occurs.
+//
+// If the transaction fails with a retryable error it is retried according to
+// the repository's RetryPolicy, in which case fn is called again.
+//
+// The context passed to fn may be used to register callbacks with
+// AfterCommit() and to enqueue outbox records with EnqueueOutbox().
 func (rr *ResourceRepository) UpdateResourceVersionFn(
 	ctx context.Context,
 	r, c, n []byte,
 	fn func(context.Context, *sql.Tx) error,
 ) (ok bool, err error) {
-	return rr.withTx(ctx, func(d Driver, tx *sql.Tx) (bool, error) {
+	ctx = rr.hookContext(ctx)
+
+	var callbacks *afterCommitCallbacks
+
+	ok, err = rr.withTx(ctx, func(d Driver, tx *sql.Tx) (bool, error) {
+		// Discard any callbacks registered during a previous attempt.
+		callbacks = &afterCommitCallbacks{}
+
 		ok, err := d.UpdateVersion(ctx, tx, rr.key, r, c, n)
 		if !ok || err != nil {
 			return false, err
 		}
 
+		ctx := withOutbox(withAfterCommit(ctx, callbacks), d, rr.key)
 		return true, fn(ctx, tx)
 	})
+
+	if ok {
+		callbacks.run()
+	}
+
+	return ok, err
 }
 
 // DeleteResource removes all information about the resource r.
 func (rr *ResourceRepository) DeleteResource(ctx context.Context, r []byte) error {
+	ctx = rr.hookContext(ctx)
+
 	return rr.withDriver(ctx, func(d Driver) error {
 		return d.DeleteResource(ctx, rr.db, rr.key, r)
 	})
 }
 
+// DeleteAllResources removes all information about all resources.
+//
+// If the repository belongs to a handler created by New() that implements
+// ResettableMessageHandler, the handler's Reset() method is called within the
+// same transaction.
+//
+// If the repository belongs to a handler created by New() with the
+// WithHandlerSchema() option, the handler schema is dropped and recreated
+// within the same transaction.
+func (rr *ResourceRepository) DeleteAllResources(ctx context.Context) error {
+	ctx = rr.hookContext(ctx)
+
+	var callbacks *afterCommitCallbacks
+
+	ok, err := rr.withTx(ctx, func(d Driver, tx *sql.Tx) (bool, error) {
+		// Discard any callbacks registered during a previous attempt.
+		callbacks = &afterCommitCallbacks{}
+
+		if err := d.DeleteAllResources(ctx, tx, rr.key); err != nil {
+			return false, err
+		}
+
+		if rr.reset != nil {
+			ctx := withOutbox(withAfterCommit(ctx, callbacks), d, rr.key)
+			if err := rr.reset(ctx, tx); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	})
+
+	if ok {
+		callbacks.run()
+	}
+
+	return err
+}
+
+// RenameHandlerKey moves the versions of all resources stored for the handler
+// key o such that they are stored for the handler key n instead.
+//
+// The resource versions are moved within a single transaction.
+func (rr *ResourceRepository) RenameHandlerKey(ctx context.Context, o, n string) error {
+	ctx = rr.hookContext(ctx)
+
+	ok, err := rr.withTx(ctx, func(d Driver, tx *sql.Tx) (bool, error) {
+		return d.RenameHandlerKey(ctx, tx, o, n)
+	})
+
+	if err == nil && !ok {
+		return resource.ErrHandlerKeyInUse
+	}
+
+	return err
+}
+
+// ListResources returns an iterator over the resources in the repository and
+// their current versions.
+//
+// Each page of resources is fetched using a separate query.
+func (rr *ResourceRepository) ListResources(ctx context.Context) iter.Seq2[resource.Item, error] {
+	ctx = rr.hookContext(ctx)
+
+	return func(yield func(resource.Item, error) bool) {
+		d, err := rr.driver(ctx)
+		if err != nil {
+			yield(resource.Item{}, err)
+			return
+		}
+
+		var after []byte
+
+		for {
+			items, err := d.QueryVersions(ctx, rr.db, rr.key, after, listPageSize)
+			if err != nil {
+				yield(resource.Item{}, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if len(items) < listPageSize {
+				return
+			}
+
+			after = items[len(items)-1].Resource
+		}
+	}
+}
+
+// listPageSize is the maximum number of resources that are fetched by each
+// query performed by ResourceRepository.ListResources().
+const listPageSize = 100
+
 // withDriver calls fn with the driver that should be used to perform SQL
 // operations of rr.db.
 func (rr *ResourceRepository) withDriver(
 	ctx context.Context,
 	fn func(Driver) error,
 ) error {
-	d, err := rr.cs.resolve(ctx)
+	d, err := rr.driver(ctx)
 	if err != nil {
 		return err
 	}
@@ -109,6 +259,26 @@ func (rr *ResourceRepository) withDriver(
 	return fn(d)
 }
 
+// driver returns the driver that should be used to perform SQL operations on
+// rr.db.
+//
+// If the repository was created with WithAutoCreateSchema(true), it ensures
+// that the schema exists before the driver is returned.
+func (rr *ResourceRepository) driver(ctx context.Context) (Driver, error) {
+	d, err := rr.cs.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if rr.autoCreateSchema {
+		if err := rr.ensureSchema(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
 // withTx calls fn with the driver that should be used to perform SQL operations
 // of rr.db.
 //
@@ -123,24 +293,119 @@ func (rr *ResourceRepository) withTx(
 	err := rr.withDriver(
 		ctx,
 		func(d Driver) error {
-			tx, err := rr.db.BeginTx(ctx, nil)
-			if err != nil {
+			if s, isRetrier := d.(savepointRetrier); isRetrier {
+				var err error
+				ok, err = rr.savepointTx(ctx, d, s.retrySavepoint(), fn)
 				return err
 			}
-			defer tx.Rollback() // nolint:errcheck
 
-			ok, err = fn(d, tx)
-			if err != nil {
-				return err
+			return rr.retry.do(
+				ctx,
+				d.IsRetryableError,
+				func() error {
+					var err error
+					ok, err = rr.tx(ctx, d, fn)
+					return err
+				},
+			)
+		},
+	)
+
+	return ok && err == nil, err
+}
+
+// tx calls fn within a single transaction.
+//
+// The transaction is committed if fn returns true, otherwise it is rolled
+// back.
+func (rr *ResourceRepository) tx(
+	ctx context.Context,
+	d Driver,
+	fn func(Driver, *sql.Tx) (bool, error),
+) (bool, error) {
+	if err := d.ValidateTxOptions(rr.txOptions); err != nil {
+		return false, err
+	}
+
+	tx, err := rr.db.BeginTx(ctx, rr.txOptions)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	ok, err := fn(d, tx)
+	if err != nil {
+		return false, err
+	}
+
+	if ok {
+		return true, tx.Commit()
+	}
+
+	return false, tx.Rollback()
+}
+
+// savepointTx calls fn within a single transaction, retrying it according to
+// rr.retry by rolling back to the savepoint sp.
+//
+// The transaction is committed if fn returns true, otherwise it is rolled
+// back.
+func (rr *ResourceRepository) savepointTx(
+	ctx context.Context,
+	d Driver,
+	sp string,
+	fn func(Driver, *sql.Tx) (bool, error),
+) (bool, error) {
+	if err := d.ValidateTxOptions(rr.txOptions); err != nil {
+		return false, err
+	}
+
+	tx, err := rr.db.BeginTx(ctx, rr.txOptions)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT `+sp); err != nil {
+		return false, err
+	}
+
+	var (
+		ok      bool
+		attempt int
+	)
+
+	err = rr.retry.do(
+		ctx,
+		d.IsRetryableError,
+		func() error {
+			attempt++
+
+			if attempt > 1 {
+				if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+sp); err != nil {
+					return err
+				}
 			}
 
-			if ok {
-				return tx.Commit()
+			var err error
+			ok, err = fn(d, tx)
+			if !ok || err != nil {
+				return err
 			}
 
-			return tx.Rollback()
+			// Releasing the savepoint is where a transaction that can not be
+			// committed reports a retryable error.
+			_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT `+sp)
+			return err
 		},
 	)
+	if err != nil {
+		return false, err
+	}
 
-	return ok && err == nil, err
+	if ok {
+		return true, tx.Commit()
+	}
+
+	return false, tx.Rollback()
 }
@@ -0,0 +1,92 @@
+package sqlprojection
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy determines how transactions that fail with a retryable error
+// are retried.
+//
+// An error is retryable if the Driver's IsRetryableError() method returns
+// true. The entire transaction is retried, including any user-defined
+// operations such as the handler's HandleEvent() method. Such operations MUST
+// therefore not have side-effects outside of the transaction.
+//
+// Drivers that support it, such as CockroachDriver, retry the transaction by
+// rolling back to a savepoint, rather than by beginning a new transaction.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the transaction is attempted,
+	// including the first attempt. A value of 1 or less disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between retries. The delay is doubled
+	// after each retry, up to this limit.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the RetryPolicy used when no WithRetryPolicy() option
+// is provided.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     500 * time.Millisecond,
+}
+
+// WithRetryPolicy returns an Option that sets the policy used to retry
+// transactions that fail with a retryable error.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return Option{
+		applyToRepository: func(rr *ResourceRepository) {
+			rr.retry = p
+		},
+	}
+}
+
+// do calls fn until it succeeds, returns a non-retryable error, or the
+// maximum number of attempts is reached.
+func (p RetryPolicy) do(
+	ctx context.Context,
+	isRetryable func(error) bool,
+	fn func() error,
+) error {
+	backoff := p.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		// Sleep for somewhere between half and all of the backoff period, so
+		// that competing transactions are less likely to collide again.
+		delay := backoff / 2
+		if delay > 0 {
+			delay += rand.N(delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		backoff *= 2
+		if backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// savepointRetrier is an interface for drivers that retry transactions by
+// rolling back to a savepoint within the same transaction, rather than by
+// beginning a new transaction.
+type savepointRetrier interface {
+	// retrySavepoint returns the name of the savepoint that is established at
+	// the start of each transaction.
+	retrySavepoint() string
+}
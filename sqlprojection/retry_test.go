@@ -0,0 +1,232 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dogmatiq/dogma"
+	. "github.com/dogmatiq/enginekit/enginetest/stubs"
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/projectionkit/sqlprojection/fixtures" // can't dot-import due to conflict
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func IsRetryableError() (driver implementations)", func() {
+	table.DescribeTable(
+		"it classifies errors",
+		func(d Driver, err error, expect bool) {
+			Expect(d.IsRetryableError(err)).To(Equal(expect))
+		},
+		table.Entry("PostgreSQL serialization failure (pgx)", PostgresDriver, sqlStateError("40001"), true),
+		table.Entry("PostgreSQL deadlock (pgx)", PostgresDriver, sqlStateError("40P01"), true),
+		table.Entry("PostgreSQL serialization failure (pq)", PostgresDriver, pqError("40001"), true),
+		table.Entry("PostgreSQL deadlock (pq)", PostgresDriver, pqError("40P01"), true),
+		table.Entry("PostgreSQL wrapped error", PostgresDriver, fmt.Errorf("<context>: %w", sqlStateError("40001")), true),
+		table.Entry("PostgreSQL unique violation", PostgresDriver, sqlStateError("23505"), false),
+		table.Entry("PostgreSQL unrecognized error", PostgresDriver, errors.New("<error>"), false),
+		table.Entry("CockroachDB transaction retry error (pgx)", CockroachDriver, sqlStateError("40001"), true),
+		table.Entry("CockroachDB transaction retry error (pq)", CockroachDriver, pqError("40001"), true),
+		table.Entry("CockroachDB wrapped error", CockroachDriver, fmt.Errorf("<context>: %w", sqlStateError("40001")), true),
+		table.Entry("CockroachDB unique violation", CockroachDriver, sqlStateError("23505"), false),
+		table.Entry("CockroachDB unrecognized error", CockroachDriver, errors.New("<error>"), false),
+		table.Entry("MySQL deadlock", MySQLDriver, errors.New("Error 1213: Deadlock found when trying to get lock"), true),
+		table.Entry("MySQL deadlock with SQLSTATE", MySQLDriver, errors.New("Error 1213 (40001): Deadlock found when trying to get lock"), true),
+		table.Entry("MySQL wrapped error", MySQLDriver, fmt.Errorf("<context>: %w", errors.New("Error 1213: Deadlock found when trying to get lock")), true),
+		table.Entry("MySQL duplicate entry", MySQLDriver, errors.New("Error 1062: Duplicate entry"), false),
+		table.Entry("SQLite locked database", SQLiteDriver, errors.New("database is locked"), true),
+		table.Entry("SQLite locked table", SQLiteDriver, errors.New("database table is locked"), true),
+		table.Entry("SQLite constraint failure", SQLiteDriver, errors.New("UNIQUE constraint failed"), false),
+	)
+})
+
+var _ = Describe("func WithRetryPolicy()", func() {
+	var handler *fixtures.MessageHandler
+
+	BeforeEach(func() {
+		handler = &fixtures.MessageHandler{}
+		handler.ConfigureFunc = func(c dogma.ProjectionConfigurer) {
+			c.Identity("<projection>", "<key>")
+		}
+	})
+
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+					driver   Driver
+					calls    int
+				)
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					d, err := SelectDriver(ctx, db, BuiltInDrivers())
+					Expect(err).ShouldNot(HaveOccurred())
+					driver = retryableDriver{d}
+
+					calls = 0
+					handler.HandleEventFunc = func(
+						context.Context,
+						*sql.Tx,
+						dogma.ProjectionEventScope,
+						dogma.Event,
+					) error {
+						calls++
+						if calls < 3 {
+							return errRetryable
+						}
+						return nil
+					}
+				})
+
+				AfterEach(func() {
+					err := DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("retries the transaction if it fails with a retryable error", func() {
+					adaptor := New(
+						db,
+						handler,
+						WithDriver(driver),
+						WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+					)
+
+					ok, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeTrue())
+					Expect(calls).To(Equal(3))
+
+					ver, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ver).To(Equal([]byte("<version>")))
+				})
+
+				It("returns the error if the maximum number of attempts is reached", func() {
+					adaptor := New(
+						db,
+						handler,
+						WithDriver(driver),
+						WithRetryPolicy(RetryPolicy{MaxAttempts: 2}),
+					)
+
+					_, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).To(Equal(errRetryable))
+					Expect(calls).To(Equal(2))
+
+					ver, err := adaptor.ResourceVersion(ctx, []byte("<resource>"))
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ver).To(BeEmpty())
+				})
+
+				It("does not retry the transaction if the error is not retryable", func() {
+					handler.HandleEventFunc = func(
+						context.Context,
+						*sql.Tx,
+						dogma.ProjectionEventScope,
+						dogma.Event,
+					) error {
+						calls++
+						return errors.New("<error>")
+					}
+
+					adaptor := New(
+						db,
+						handler,
+						WithDriver(driver),
+						WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+					)
+
+					_, err := adaptor.HandleEvent(
+						ctx,
+						[]byte("<resource>"),
+						nil,
+						[]byte("<version>"),
+						nil,
+						EventA1,
+					)
+					Expect(err).To(MatchError("<error>"))
+					Expect(calls).To(Equal(1))
+				})
+			},
+		)
+	}
+})
+
+// errRetryable is an error that is considered retryable by retryableDriver.
+var errRetryable = errors.New("<retryable>")
+
+// retryableDriver is a Driver that treats errRetryable as retryable.
+type retryableDriver struct {
+	Driver
+}
+
+func (d retryableDriver) IsRetryableError(err error) bool {
+	return errors.Is(err, errRetryable)
+}
+
+// sqlStateError is an error that exposes its SQLSTATE code in the same manner
+// as pgconn.PgError.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "SQLSTATE " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+// pqError is an error that exposes its SQLSTATE code in the same manner as
+// pq.Error.
+type pqError string
+
+func (e pqError) Error() string { return "pq: " + string(e) }
+
+func (e pqError) Get(k byte) string {
+	if k == 'C' {
+		return string(e)
+	}
+	return ""
+}
@@ -3,12 +3,16 @@ package sqlprojection
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
 )
 
 // CreateSchema creates the schema elements necessary to store projections on
 // the given database.
 //
-// It does not return an error if the schema already exists.
+// It does not return an error if the schema already exists. The built-in
+// drivers also apply any outstanding migrations, as per MigrateSchema().
 //
 // If no candidate drivers are provided all built-in drivers are considered as
 // candidates.
@@ -42,3 +46,111 @@ func DropSchema(ctx context.Context, db *sql.DB, options ...Option) error {
 
 	return d.DropSchema(ctx, db)
 }
+
+// MigrateSchema creates the schema elements necessary to store projections on
+// the given database, or upgrades them to the latest version if they already
+// exist.
+//
+// It is safe to call MigrateSchema() concurrently, including from multiple
+// processes.
+//
+// If no candidate drivers are provided all built-in drivers are considered as
+// candidates.
+func MigrateSchema(ctx context.Context, db *sql.DB, options ...Option) error {
+	var cs candidateSet
+	cs.init(db, options)
+
+	d, err := cs.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	return d.MigrateSchema(ctx, db)
+}
+
+// SchemaVersion returns the version of the schema elements in the given
+// database, and the latest version supported by the driver.
+//
+// current is 0 if the schema has not been created. If current is less than
+// latest the schema can be upgraded by calling MigrateSchema().
+//
+// If no candidate drivers are provided all built-in drivers are considered as
+// candidates.
+func SchemaVersion(ctx context.Context, db *sql.DB, options ...Option) (current, latest int, err error) {
+	var cs candidateSet
+	cs.init(db, options)
+
+	d, err := cs.resolve(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return d.SchemaVersion(ctx, db)
+}
+
+// ensureSchema creates the schema using the driver d if it has not been
+// created already.
+//
+// The schema version is queried before any schema elements are created, such
+// that no DDL statements are executed if the schema already exists. An
+// existing schema is not upgraded, as some migrations must be applied while no
+// projections are being updated; see MigrateSchema().
+//
+// An OCC table created before the built-in drivers recorded schema versions
+// has a schema version of 0. It is not treated as a missing schema, as creating
+// the schema would upgrade the existing table.
+//
+// The check is performed at most once for the lifetime of rr.
+func (rr *ResourceRepository) ensureSchema(ctx context.Context, d Driver) error {
+	if atomic.LoadUint32(&rr.schemaEnsured) != 0 {
+		return nil
+	}
+
+	if err := rr.schemaM.Lock(ctx); err != nil {
+		return err
+	}
+	defer rr.schemaM.Unlock()
+
+	// Ensure that another goroutine did not create the schema while we were
+	// waiting to acquire the mutex.
+	if atomic.LoadUint32(&rr.schemaEnsured) != 0 {
+		return nil
+	}
+
+	current, latest, err := d.SchemaVersion(ctx, rr.db)
+	if err != nil {
+		return fmt.Errorf("unable to query the schema version: %w", err)
+	}
+
+	if current == 0 {
+		if m, ok := d.(schemaMigrator); ok {
+			exists, err := m.tableExists(ctx, rr.db, m.table())
+			if err != nil {
+				return fmt.Errorf("unable to query the schema version: %w", err)
+			}
+
+			if exists {
+				return fmt.Errorf(
+					"the schema is out of date (unversioned, latest is %d), see MigrateSchema()",
+					latest,
+				)
+			}
+		}
+
+		if err := d.CreateSchema(ctx, rr.db); err != nil {
+			return fmt.Errorf("unable to create the schema: %w", err)
+		}
+
+		if rr.cs.logger != nil {
+			rr.cs.logger.InfoContext(
+				ctx,
+				"created SQL projection schema",
+				slog.String("driver", fmt.Sprintf("%T", d)),
+			)
+		}
+	}
+
+	atomic.StoreUint32(&rr.schemaEnsured, 1)
+
+	return nil
+}
@@ -1,9 +1,12 @@
 package sqlprojection_test
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	. "github.com/dogmatiq/projectionkit/sqlprojection"
@@ -13,7 +16,7 @@ import (
 )
 
 var _ = Context("creating and dropping schema", func() {
-	for _, pair := range sqltest.CompatiblePairs() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
 		pair := pair // capture loop variable
 
 		When(
@@ -56,6 +59,33 @@ var _ = Context("creating and dropping schema", func() {
 						err = CreateSchema(ctx, db)
 						Expect(err).ShouldNot(HaveOccurred())
 					})
+
+					It("supports long handler keys, resources and versions", func() {
+						err := CreateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db)
+
+						key := strings.Repeat("k", 1000)
+						res := bytes.Repeat([]byte("r"), 1000)
+						ver := bytes.Repeat([]byte("v"), 1000)
+
+						repo := NewResourceRepository(db, key)
+
+						ok, err := repo.UpdateResourceVersion(ctx, res, nil, ver)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(ok).To(BeTrue())
+
+						v, err := repo.ResourceVersion(ctx, res)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(v).To(Equal(ver))
+
+						// Resources that share a long prefix must remain
+						// distinct.
+						other := append(bytes.Clone(res), 'x')
+						v, err = repo.ResourceVersion(ctx, other)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(v).To(BeEmpty())
+					})
 				})
 
 				Describe("func DropSchema()", func() {
@@ -75,7 +105,315 @@ var _ = Context("creating and dropping schema", func() {
 						Expect(err).ShouldNot(HaveOccurred())
 					})
 				})
+
+				Describe("func MigrateSchema()", func() {
+					It("upgrades the schema to the latest version", func() {
+						current, latest, err := SchemaVersion(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(0))
+						Expect(latest).To(BeNumerically(">=", 1))
+
+						err = MigrateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db)
+
+						current, _, err = SchemaVersion(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(latest))
+					})
+
+					It("preserves resource versions stored using the original MySQL layout", func() {
+						d, err := SelectDriver(ctx, db, BuiltInDrivers())
+						Expect(err).ShouldNot(HaveOccurred())
+
+						if d.Dialect() != MySQLDialect {
+							Skip("the original layout is specific to MySQL")
+						}
+
+						_, err = db.ExecContext(
+							ctx,
+							`CREATE TABLE projection_occ (
+								handler  VARBINARY(255) NOT NULL,
+								resource VARBINARY(255) NOT NULL,
+								version  VARBINARY(255) NOT NULL,
+
+								PRIMARY KEY (handler, resource)
+							) ENGINE=InnoDB`,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db)
+
+						_, err = db.ExecContext(
+							ctx,
+							`INSERT INTO projection_occ VALUES
+								('<key>', '<resource-1>', '<version-1>'),
+								('<key>', '<resource-2>', '<version-2>'),
+								('<other>', '<resource-1>', '<version-3>')`,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						err = MigrateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						expect := func() {
+							repo := NewResourceRepository(db, "<key>")
+
+							v, err := repo.ResourceVersion(ctx, []byte("<resource-1>"))
+							Expect(err).ShouldNot(HaveOccurred())
+							Expect(v).To(Equal([]byte("<version-1>")))
+
+							v, err = repo.ResourceVersion(ctx, []byte("<resource-2>"))
+							Expect(err).ShouldNot(HaveOccurred())
+							Expect(v).To(Equal([]byte("<version-2>")))
+
+							v, err = NewResourceRepository(db, "<other>").ResourceVersion(ctx, []byte("<resource-1>"))
+							Expect(err).ShouldNot(HaveOccurred())
+							Expect(v).To(Equal([]byte("<version-3>")))
+						}
+
+						expect()
+
+						// Simulate a previous attempt that was interrupted after
+						// the OCC table was replaced, but before the legacy
+						// table was dropped and the version was recorded.
+						_, err = db.ExecContext(ctx, `CREATE TABLE projection_occ_legacy (id INT NOT NULL)`)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						_, err = db.ExecContext(ctx, `DELETE FROM projection_occ_schema_version WHERE version >= 3`)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						err = MigrateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						current, latest, err := SchemaVersion(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(latest))
+
+						expect()
+					})
+
+					It("preserves handler versions stored using the original MySQL layout", func() {
+						d, err := SelectDriver(ctx, db, BuiltInDrivers())
+						Expect(err).ShouldNot(HaveOccurred())
+
+						if d.Dialect() != MySQLDialect {
+							Skip("the original layout is specific to MySQL")
+						}
+
+						_, err = db.ExecContext(
+							ctx,
+							`CREATE TABLE projection_occ_handler_version (
+								handler    VARBINARY(255) NOT NULL,
+								version    INT NOT NULL,
+								applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+								PRIMARY KEY (handler, version)
+							) ENGINE=InnoDB`,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db)
+
+						_, err = db.ExecContext(
+							ctx,
+							`INSERT INTO projection_occ_handler_version (handler, version) VALUES
+								('<key>', 1),
+								('<key>', 2)`,
+						)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						err = MigrateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						var v int
+						err = db.QueryRowContext(
+							ctx,
+							`SELECT MAX(version)
+							FROM projection_occ_handler_version
+							WHERE handler_id = UNHEX(SHA2('<key>', 256))`,
+						).Scan(&v)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(v).To(Equal(2))
+					})
+
+					It("can be called when the schema is already up-to-date", func() {
+						err := MigrateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db)
+
+						err = MigrateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+					})
+
+					It("can be called concurrently", func() {
+						defer DropSchema(ctx, db)
+
+						var g sync.WaitGroup
+						errs := make([]error, 5)
+
+						for i := range errs {
+							g.Add(1)
+							go func() {
+								defer g.Done()
+								errs[i] = MigrateSchema(ctx, db)
+							}()
+						}
+
+						g.Wait()
+
+						for _, err := range errs {
+							Expect(err).ShouldNot(HaveOccurred())
+						}
+					})
+				})
+
+				Describe("func SchemaVersion()", func() {
+					It("returns zero after the schema is dropped", func() {
+						err := MigrateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						err = DropSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						current, _, err := SchemaVersion(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(0))
+					})
+				})
+
+				Describe("func WithAutoCreateSchema()", func() {
+					It("creates the schema before the first operation", func() {
+						defer DropSchema(ctx, db)
+
+						repo := NewResourceRepository(db, "<key>", WithAutoCreateSchema(true))
+						err := repo.StoreResourceVersion(ctx, []byte("<resource>"), []byte("<version>"))
+						Expect(err).ShouldNot(HaveOccurred())
+
+						current, latest, err := SchemaVersion(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(latest))
+
+						v, err := repo.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(v).To(Equal([]byte("<version>")))
+					})
+
+					It("uses the existing schema if it has already been created", func() {
+						err := CreateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db)
+
+						err = NewResourceRepository(db, "<key>").StoreResourceVersion(ctx, []byte("<resource>"), []byte("<version>"))
+						Expect(err).ShouldNot(HaveOccurred())
+
+						repo := NewResourceRepository(db, "<key>", WithAutoCreateSchema(true))
+						v, err := repo.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(v).To(Equal([]byte("<version>")))
+					})
+
+					It("does not upgrade an OCC table that was created before schema versions were recorded", func() {
+						opt := WithTableName("", "auto_create_occ")
+
+						err := CreateSchema(ctx, db, opt)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db, opt)
+
+						_, err = db.ExecContext(ctx, `DROP TABLE auto_create_occ_schema_version`)
+						Expect(err).ShouldNot(HaveOccurred())
+
+						current, latest, err := SchemaVersion(ctx, db, opt)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(0))
+
+						repo := NewResourceRepository(db, "<key>", opt, WithAutoCreateSchema(true))
+						_, err = repo.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).To(MatchError(
+							fmt.Sprintf(
+								"the schema is out of date (unversioned, latest is %d), see MigrateSchema()",
+								latest,
+							),
+						))
+
+						current, _, err = SchemaVersion(ctx, db, opt)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(0))
+					})
+
+					It("can be called concurrently", func() {
+						defer DropSchema(ctx, db)
+
+						repo := NewResourceRepository(db, "<key>", WithAutoCreateSchema(true))
+
+						var g sync.WaitGroup
+						errs := make([]error, 5)
+
+						for i := range errs {
+							g.Add(1)
+							go func() {
+								defer g.Done()
+								_, errs[i] = repo.ResourceVersion(ctx, []byte("<resource>"))
+							}()
+						}
+
+						g.Wait()
+
+						for _, err := range errs {
+							Expect(err).ShouldNot(HaveOccurred())
+						}
+					})
+
+					It("does not create the schema when disabled by a later option", func() {
+						repo := NewResourceRepository(
+							db,
+							"<key>",
+							WithAutoCreateSchema(true),
+							WithAutoCreateSchema(false),
+						)
+
+						_, err := repo.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).Should(HaveOccurred())
+
+						current, _, err := SchemaVersion(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(current).To(Equal(0))
+					})
+				})
+
+				Describe("func WithTableName()", func() {
+					It("stores resource versions in the named table", func() {
+						opt := WithTableName("", `custom "occ" table`)
+
+						err := CreateSchema(ctx, db, opt)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db, opt)
+
+						err = CreateSchema(ctx, db)
+						Expect(err).ShouldNot(HaveOccurred())
+						defer DropSchema(ctx, db)
+
+						custom := NewResourceRepository(db, "<key>", opt)
+						err = custom.StoreResourceVersion(ctx, []byte("<resource>"), []byte("<version>"))
+						Expect(err).ShouldNot(HaveOccurred())
+
+						v, err := custom.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(v).To(Equal([]byte("<version>")))
+
+						def := NewResourceRepository(db, "<key>")
+						v, err = def.ResourceVersion(ctx, []byte("<resource>"))
+						Expect(err).ShouldNot(HaveOccurred())
+						Expect(v).To(BeEmpty())
+					})
+				})
 			},
 		)
 	}
 })
+
+var _ = Describe("func WithTableName()", func() {
+	It("panics if the table name is empty", func() {
+		Expect(func() {
+			WithTableName("<schema>", "")
+		}).To(PanicWith("sqlprojection.WithTableName() requires a non-empty table name"))
+	})
+})
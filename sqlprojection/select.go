@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"sync/atomic"
 
 	"github.com/dogmatiq/cosyne"
@@ -62,26 +63,46 @@ func WithCandidateDrivers(drivers ...Driver) Option {
 // candidateSet is a set of drivers that are candidates for use with a
 // particular database.
 type candidateSet struct {
-	m          cosyne.Mutex
-	resolved   uint32
-	db         *sql.DB
-	candidates []Driver
+	m             cosyne.Mutex
+	resolved      uint32
+	db            *sql.DB
+	candidates    []Driver
+	logger        *slog.Logger
+	table         *tableName
+	notifications bool
 }
 
 // init sets up the candidate set.
 //
-// If options is empty the default options are applied.
+// If none of the options specify any drivers the built-in drivers are used as
+// candidates.
 func (s *candidateSet) init(db *sql.DB, options []Option) {
 	s.db = db
 
-	if len(options) == 0 {
-		options = []Option{
-			WithCandidateDrivers(BuiltInDrivers()...),
+	for _, opt := range options {
+		if opt.applyToCandidateSet != nil {
+			opt.applyToCandidateSet(s)
 		}
 	}
 
-	for _, opt := range options {
-		opt.applyToCandidateSet(s)
+	if len(s.candidates) == 0 {
+		s.candidates = BuiltInDrivers()
+	}
+
+	if s.table != nil {
+		for i, d := range s.candidates {
+			if d, ok := d.(tableNameConfigurable); ok {
+				s.candidates[i] = d.withTableName(*s.table)
+			}
+		}
+	}
+
+	if s.notifications {
+		for i, d := range s.candidates {
+			if d, ok := d.(notificationConfigurable); ok {
+				s.candidates[i] = d.withNotifications(true)
+			}
+		}
 	}
 }
 
@@ -102,9 +123,24 @@ func (s *candidateSet) resolve(ctx context.Context) (Driver, error) {
 			// If not, it's our turn to try selection.
 			d, err := SelectDriver(ctx, s.db, s.candidates)
 			if err != nil {
+				if s.logger != nil {
+					s.logger.ErrorContext(
+						ctx,
+						"unable to select SQL driver",
+						slog.Any("error", err),
+					)
+				}
 				return nil, err
 			}
 
+			if s.logger != nil {
+				s.logger.DebugContext(
+					ctx,
+					"selected SQL driver",
+					slog.String("driver", fmt.Sprintf("%T", d)),
+				)
+			}
+
 			s.db = nil
 			s.candidates = []Driver{d}
 			atomic.StoreUint32(&s.resolved, 1)
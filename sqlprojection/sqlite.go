@@ -3,6 +3,10 @@ package sqlprojection
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/dogmatiq/projectionkit/resource"
 )
 
 // SQLiteDriver is Driver for SQLite.
@@ -11,9 +15,33 @@ import (
 // SQLite v3 compatible databases and $1-style placeholders.
 var SQLiteDriver Driver = sqliteDriver{}
 
-type sqliteDriver struct{}
+type sqliteDriver struct {
+	name tableName
+}
+
+func (d sqliteDriver) withTableName(n tableName) Driver {
+	d.name = n
+	return d
+}
+
+// table returns the name of the table used to store resource versions.
+func (d sqliteDriver) table() tableName {
+	if d.name.Table == "" {
+		return tableName{"", "projection_occ"}
+	}
+	return d.name
+}
 
-func (sqliteDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
+// occ returns the quoted name of the table used to store resource versions.
+func (d sqliteDriver) occ() string {
+	return d.table().qualified(quoteANSI)
+}
+
+func (d sqliteDriver) Dialect() Dialect {
+	return SQLiteDialect
+}
+
+func (d sqliteDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
 	// Verify that we're using SQLite and that $1-style placeholders are
 	// supported.
 	_, err := db.ExecContext(
@@ -24,26 +52,201 @@ func (sqliteDriver) IsCompatibleWith(ctx context.Context, db *sql.DB) error {
 	return err
 }
 
-func (sqliteDriver) CreateSchema(ctx context.Context, db *sql.DB) error {
-	_, err := db.ExecContext(
+func (d sqliteDriver) CreateSchema(ctx context.Context, db *sql.DB) error {
+	return d.MigrateSchema(ctx, db)
+}
+
+func (d sqliteDriver) DropSchema(ctx context.Context, db *sql.DB) error {
+	for _, t := range []string{
+		d.occ(),
+		d.schemaVersionTable(),
+		d.handlerVersionTable(),
+		d.outboxTable(),
+	} {
+		if _, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS `+t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d sqliteDriver) MigrateSchema(ctx context.Context, db *sql.DB) error {
+	return migrateSchema(ctx, db, d)
+}
+
+func (d sqliteDriver) MigrateHandlerSchema(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	migrations []Migration,
+) error {
+	return migrateHandlerSchema(ctx, db, d, h, migrations)
+}
+
+func (d sqliteDriver) SchemaVersion(ctx context.Context, db *sql.DB) (int, int, error) {
+	return schemaVersion(ctx, db, d)
+}
+
+// schemaVersionTable returns the quoted name of the table used to record
+// which migrations have been applied.
+func (d sqliteDriver) schemaVersionTable() string {
+	return d.table().withSuffix("_schema_version").qualified(quoteANSI)
+}
+
+// handlerVersionTable returns the quoted name of the table used to record
+// which handler migrations have been applied.
+func (d sqliteDriver) handlerVersionTable() string {
+	return d.table().withSuffix("_handler_version").qualified(quoteANSI)
+}
+
+// outboxTable returns the quoted name of the table used to store outbox
+// records.
+func (d sqliteDriver) outboxTable() string {
+	return d.table().withSuffix("_outbox").qualified(quoteANSI)
+}
+
+func (d sqliteDriver) migrations() []migration {
+	return []migration{
+		{
+			Version: 1,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.occ() + ` (
+					handler  BINARY NOT NULL,
+					resource BINARY NOT NULL,
+					version  BINARY NOT NULL,
+
+					PRIMARY KEY (handler, resource)
+				)`,
+			},
+		},
+		{
+			Version: 2,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.handlerVersionTable() + ` (
+					handler    BINARY NOT NULL,
+					version    INTEGER NOT NULL,
+					applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+					PRIMARY KEY (handler, version)
+				)`,
+			},
+		},
+		{
+			Version: 3,
+			Statements: []string{
+				`CREATE TABLE IF NOT EXISTS ` + d.outboxTable() + ` (
+					id      INTEGER PRIMARY KEY AUTOINCREMENT,
+					handler BINARY NOT NULL,
+					topic   TEXT NOT NULL,
+					payload BINARY NOT NULL
+				)`,
+				// SQLite requires that the index name is qualified instead of
+				// the table name.
+				`CREATE INDEX IF NOT EXISTS ` + d.table().withSuffix("_outbox_handler").qualified(quoteANSI) + `
+				ON ` + quoteANSI(d.table().Table+"_outbox") + ` (handler, id)`,
+			},
+		},
+	}
+}
+
+func (d sqliteDriver) lockSchema(context.Context, *sql.Conn) (func() error, error) {
+	// SQLite does not support advisory locks. Instead, concurrent migrations
+	// are prevented by SQLite's own database-level write lock, which causes
+	// competing transactions to fail with a retryable error.
+	return func() error { return nil }, nil
+}
+
+func (d sqliteDriver) createSchemaVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(
 		ctx,
-		`CREATE TABLE IF NOT EXISTS projection_occ (
-			handler  BINARY NOT NULL,
-			resource BINARY NOT NULL,
-			version  BINARY NOT NULL,
+		`CREATE TABLE IF NOT EXISTS `+d.schemaVersionTable()+` (
+			version    INTEGER NOT NULL PRIMARY KEY,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	)
+	return err
+}
+
+func (d sqliteDriver) tableExists(ctx context.Context, q rowQueryer, t tableName) (bool, error) {
+	master := "sqlite_master"
+	if t.Schema != "" {
+		master = quoteANSI(t.Schema) + "." + master
+	}
 
-			PRIMARY KEY (handler, resource)
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COUNT(*)
+		FROM `+master+`
+		WHERE type = 'table'
+		AND name = $1`,
+		t.Table,
+	)
+
+	var count int
+	err := row.Scan(&count)
+	return count != 0, err
+}
+
+func (d sqliteDriver) querySchemaVersion(ctx context.Context, q rowQueryer) (int, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(MAX(version), 0)
+		FROM `+d.schemaVersionTable(),
+	)
+
+	var v int
+	err := row.Scan(&v)
+	return v, err
+}
+
+func (d sqliteDriver) storeSchemaVersion(ctx context.Context, tx *sql.Tx, v int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.schemaVersionTable()+` (
+			version
+		) VALUES (
+			$1
 		)`,
+		v,
 	)
 	return err
 }
 
-func (sqliteDriver) DropSchema(ctx context.Context, db *sql.DB) error {
-	_, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS projection_occ`)
+func (d sqliteDriver) queryHandlerSchemaVersion(ctx context.Context, q rowQueryer, h string) (int, error) {
+	row := q.QueryRowContext(
+		ctx,
+		`SELECT
+			COALESCE(MAX(version), 0)
+		FROM `+d.handlerVersionTable()+`
+		WHERE handler = $1`,
+		h,
+	)
+
+	var v int
+	err := row.Scan(&v)
+	return v, err
+}
+
+func (d sqliteDriver) storeHandlerSchemaVersion(ctx context.Context, tx *sql.Tx, h string, v int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.handlerVersionTable()+` (
+			handler,
+			version
+		) VALUES (
+			$1,
+			$2
+		)`,
+		h,
+		v,
+	)
 	return err
 }
 
-func (sqliteDriver) StoreVersion(
+func (d sqliteDriver) StoreVersion(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -51,7 +254,7 @@ func (sqliteDriver) StoreVersion(
 ) error {
 	_, err := db.ExecContext(
 		ctx,
-		`INSERT INTO projection_occ (
+		`INSERT INTO `+d.occ()+` (
 			handler,
 			resource,
 			version
@@ -80,7 +283,7 @@ func (d sqliteDriver) UpdateVersion(
 	if len(c) == 0 {
 		res, err := tx.ExecContext(
 			ctx,
-			`INSERT INTO projection_occ (
+			`INSERT INTO `+d.occ()+` (
 				handler,
 				resource,
 				version
@@ -111,7 +314,7 @@ func (d sqliteDriver) UpdateVersion(
 		// If the "next" version is empty, we can delete the row entirely.
 		res, err = tx.ExecContext(
 			ctx,
-			`DELETE FROM projection_occ
+			`DELETE FROM `+d.occ()+`
 			WHERE handler = ?
 			AND resource = ?
 			AND version = ?`,
@@ -123,7 +326,7 @@ func (d sqliteDriver) UpdateVersion(
 		// Otherwise we simply update the existing row.
 		res, err = tx.ExecContext(
 			ctx,
-			`UPDATE projection_occ SET
+			`UPDATE `+d.occ()+` SET
 				version = ?
 			WHERE handler = ?
 			AND resource = ?
@@ -145,7 +348,7 @@ func (d sqliteDriver) UpdateVersion(
 	return count != 0, err
 }
 
-func (sqliteDriver) QueryVersion(
+func (d sqliteDriver) QueryVersion(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -155,7 +358,7 @@ func (sqliteDriver) QueryVersion(
 		ctx,
 		`SELECT
 			version
-		FROM projection_occ
+		FROM `+d.occ()+`
 		WHERE handler = ?
 		AND resource = ?`,
 		h,
@@ -172,7 +375,54 @@ func (sqliteDriver) QueryVersion(
 	return v, err
 }
 
-func (sqliteDriver) DeleteResource(
+func (d sqliteDriver) QueryVersions(
+	ctx context.Context,
+	db *sql.DB,
+	h string,
+	a []byte,
+	n int,
+) ([]resource.Item, error) {
+	if a == nil {
+		// A nil slice is treated as NULL, which never compares as less than
+		// any resource.
+		a = []byte{}
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT
+			resource,
+			version
+		FROM `+d.occ()+`
+		WHERE handler = ?
+		AND resource > ?
+		ORDER BY resource
+		LIMIT ?`,
+		h,
+		a,
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []resource.Item
+
+	for rows.Next() {
+		var item resource.Item
+
+		if err := rows.Scan(&item.Resource, &item.Version); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (d sqliteDriver) DeleteResource(
 	ctx context.Context,
 	db *sql.DB,
 	h string,
@@ -180,7 +430,7 @@ func (sqliteDriver) DeleteResource(
 ) error {
 	_, err := db.ExecContext(
 		ctx,
-		`DELETE FROM projection_occ
+		`DELETE FROM `+d.occ()+`
 		WHERE handler = ?
 		AND resource = ?`,
 		h,
@@ -189,3 +439,182 @@ func (sqliteDriver) DeleteResource(
 
 	return err
 }
+
+func (d sqliteDriver) DeleteAllResources(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.occ()+`
+		WHERE handler = ?`,
+		h,
+	)
+
+	return err
+}
+
+func (d sqliteDriver) RenameHandlerKey(
+	ctx context.Context,
+	tx *sql.Tx,
+	o, n string,
+) (bool, error) {
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT
+			COUNT(*)
+		FROM `+d.occ()+`
+		WHERE handler = ?`,
+		n,
+	)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count != 0 {
+		return false, nil
+	}
+
+	for _, t := range []string{
+		d.occ(),
+		d.outboxTable(),
+		d.handlerVersionTable(),
+	} {
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE `+t+` SET
+				handler = ?
+			WHERE handler = ?`,
+			n,
+			o,
+		); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (d sqliteDriver) EnqueueOutboxRecord(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	topic string,
+	payload []byte,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO `+d.outboxTable()+` (
+			handler,
+			topic,
+			payload
+		) VALUES (
+			?,
+			?,
+			?
+		)`,
+		h,
+		topic,
+		payload,
+	)
+	return err
+}
+
+func (d sqliteDriver) ClaimOutboxRecords(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	n int,
+) ([]OutboxRecord, error) {
+	// SQLite does not support SELECT ... FOR UPDATE. Instead, a statement that
+	// writes to the database without modifying any rows is executed first, so
+	// that tx holds SQLite's database-level write lock before the records are
+	// read.
+	if _, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.outboxTable()+`
+		WHERE 0`,
+	); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT
+			id,
+			topic,
+			payload
+		FROM `+d.outboxTable()+`
+		WHERE handler = ?
+		ORDER BY id
+		LIMIT ?`,
+		h,
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []OutboxRecord
+
+	for rows.Next() {
+		var rec OutboxRecord
+
+		if err := rows.Scan(&rec.ID, &rec.Topic, &rec.Payload); err != nil {
+			return nil, err
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, rows.Err()
+}
+
+func (d sqliteDriver) DeleteOutboxRecord(
+	ctx context.Context,
+	tx *sql.Tx,
+	h string,
+	id int64,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM `+d.outboxTable()+`
+		WHERE handler = ?
+		AND id = ?`,
+		h,
+		id,
+	)
+
+	return err
+}
+
+// IsRetryableError returns true if err indicates that the SQLite database is
+// locked by another connection.
+func (d sqliteDriver) IsRetryableError(err error) bool {
+	// The SQLite driver does not expose the error code via a method, so we
+	// inspect the message instead.
+	for ; err != nil; err = errors.Unwrap(err) {
+		m := err.Error()
+		if strings.HasPrefix(m, "database is locked") ||
+			strings.HasPrefix(m, "database table is locked") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateTxOptions returns an error if opts specifies an isolation level
+// other than SERIALIZABLE.
+//
+// SQLite transactions are always serializable.
+func (d sqliteDriver) ValidateTxOptions(opts *sql.TxOptions) error {
+	return validateTxOptions(
+		opts,
+		sql.LevelSerializable,
+	)
+}
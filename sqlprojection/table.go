@@ -0,0 +1,75 @@
+package sqlprojection
+
+import "strings"
+
+// tableName is the name of the table used to store resource versions.
+type tableName struct {
+	// Schema is the name of the schema (or database, in the case of MySQL and
+	// SQLite) that contains the table. If it is empty the table name is not
+	// qualified.
+	Schema string
+
+	// Table is the unqualified name of the table.
+	Table string
+}
+
+// WithTableName returns an Option that sets the name of the table used to
+// store resource versions.
+//
+// schema is the PostgreSQL schema, MySQL database or SQLite attached database
+// that contains the table. If it is empty the table name is not qualified and
+// is resolved by the database in the usual way.
+//
+// The same option MUST be passed to CreateSchema(), DropSchema() and New().
+// The names are quoted, so they are case-sensitive and may contain any
+// characters. It has no effect on drivers other than the built-in drivers.
+//
+// By default, the PostgreSQL and CockroachDB drivers use the "occ" table
+// within the "projection" schema, and the MySQL and SQLite drivers use the
+// unqualified "projection_occ" table.
+//
+// It panics if table is empty.
+func WithTableName(schema, table string) Option {
+	if table == "" {
+		panic("sqlprojection.WithTableName() requires a non-empty table name")
+	}
+
+	return Option{
+		applyToCandidateSet: func(s *candidateSet) {
+			s.table = &tableName{schema, table}
+		},
+	}
+}
+
+// tableNameConfigurable is an interface for drivers that support the
+// WithTableName() option.
+type tableNameConfigurable interface {
+	withTableName(tableName) Driver
+}
+
+// qualified returns the quoted, schema-qualified name of the table, using q
+// to quote each identifier.
+func (n tableName) qualified(q func(string) string) string {
+	if n.Schema == "" {
+		return q(n.Table)
+	}
+
+	return q(n.Schema) + "." + q(n.Table)
+}
+
+// withSuffix returns the name of a table in the same schema as n, with the
+// given suffix appended to the table name.
+func (n tableName) withSuffix(s string) tableName {
+	n.Table += s
+	return n
+}
+
+// quoteANSI quotes an identifier using ANSI SQL double-quotes.
+func quoteANSI(id string) string {
+	return `"` + strings.ReplaceAll(id, `"`, `""`) + `"`
+}
+
+// quoteMySQL quotes an identifier using MySQL backticks.
+func quoteMySQL(id string) string {
+	return "`" + strings.ReplaceAll(id, "`", "``") + "`"
+}
@@ -0,0 +1,204 @@
+package sqlprojection
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+const (
+	// waitInitialBackoff is the initial delay between queries performed by
+	// WaitForResourceVersion() when the driver does not support notifications.
+	waitInitialBackoff = 10 * time.Millisecond
+
+	// waitMaxBackoff is the maximum delay between queries performed by
+	// WaitForResourceVersion(). It also limits how long a driver that does
+	// support notifications waits before querying the version again, in case
+	// a notification is missed.
+	waitMaxBackoff = 1 * time.Second
+)
+
+// WaitForResourceVersion blocks until the version of the resource r, as stored
+// for the handler with the given key, is v or sorts after v, or until ctx is
+// canceled.
+//
+// It is a convenience for NewResourceRepository(db, key, options...)
+// followed by a call to ResourceRepository.WaitForResourceVersion().
+func WaitForResourceVersion(
+	ctx context.Context,
+	db *sql.DB,
+	key string,
+	r, v []byte,
+	options ...Option,
+) error {
+	return NewResourceRepository(db, key, options...).WaitForResourceVersion(ctx, r, v)
+}
+
+// WaitForResourceVersion blocks until the version of the resource r is v or
+// sorts after v, or until ctx is canceled.
+//
+// Versions are compared byte-wise, as per bytes.Compare(). Engines that encode
+// versions such that they sort in the order they are produced, such as
+// fixed-width big-endian offsets, can therefore wait for a version that is
+// superseded before the wait begins. For other encodings the version must
+// match v exactly.
+//
+// On PostgreSQL with the "pgx" driver it waits for notifications sent when
+// versions are updated, as per WithNotifications(), but still queries the
+// version at least once per second. Otherwise, it queries the version
+// repeatedly, with exponential backoff.
+func (rr *ResourceRepository) WaitForResourceVersion(
+	ctx context.Context,
+	r, v []byte,
+) error {
+	ctx = rr.hookContext(ctx)
+
+	return rr.withDriver(ctx, func(d Driver) error {
+		reached := func() (bool, error) {
+			c, err := d.QueryVersion(ctx, rr.db, rr.key, r)
+			return bytes.Compare(c, v) >= 0, err
+		}
+
+		if n, ok := d.(versionNotifier); ok {
+			return n.waitForNotification(ctx, rr.db, rr.key, r, reached)
+		}
+
+		return pollForVersion(ctx, reached)
+	})
+}
+
+// WithNotifications returns an Option that determines whether the driver
+// sends a notification whenever a resource version is updated, which wakes any
+// clients that are blocked in WaitForResourceVersion().
+//
+// Only the PostgreSQL driver sends notifications, using pg_notify(). It adds a
+// statement to each update, so notifications are disabled by default. Clients
+// still detect new versions if they are disabled, but only once they query the
+// version again, which may take up to one second.
+//
+// The option must be enabled by the handlers that update the versions, rather
+// than by the clients that wait for them.
+func WithNotifications(enabled bool) Option {
+	return Option{
+		applyToCandidateSet: func(s *candidateSet) {
+			s.notifications = enabled
+		},
+	}
+}
+
+// notificationConfigurable is an interface for drivers that support the
+// WithNotifications() option.
+type notificationConfigurable interface {
+	withNotifications(bool) Driver
+}
+
+// versionNotifier is an interface for drivers that notify waiting clients
+// when resource versions are updated.
+type versionNotifier interface {
+	// waitForNotification blocks until reached returns true, calling it
+	// whenever a notification is received for resource r of the handler h.
+	//
+	// If db does not support notifications it falls back to
+	// pollForVersion().
+	waitForNotification(
+		ctx context.Context,
+		db *sql.DB,
+		h string,
+		r []byte,
+		reached func() (bool, error),
+	) error
+}
+
+// pollForVersion calls reached with exponential backoff until it returns true,
+// returns an error, or ctx is canceled.
+func pollForVersion(
+	ctx context.Context,
+	reached func() (bool, error),
+) error {
+	backoff := waitInitialBackoff
+
+	for {
+		ok, err := reached()
+		if ok || err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > waitMaxBackoff {
+			backoff = waitMaxBackoff
+		}
+	}
+}
+
+// pgxNotificationWaiter returns a function that waits for a notification on
+// the connection c, which is obtained from (*sql.Conn).Raw().
+//
+// It returns false if c is not a connection from the "pgx" driver. The pgx
+// packages are accessed via reflection so that this package does not depend
+// on any specific PostgreSQL driver.
+func pgxNotificationWaiter(c any) (func(context.Context) (string, error), bool) {
+	// Connections from InterceptConnector() wrap the driver's connection.
+	if h, ok := c.(*hookConn); ok {
+		c = h.Conn
+	}
+
+	v := reflect.ValueOf(c)
+	if isNil(v) {
+		return nil, false
+	}
+
+	// stdlib.Conn.Conn() returns the underlying *pgx.Conn.
+	conn := v.MethodByName("Conn")
+	if !conn.IsValid() || conn.Type().NumIn() != 0 || conn.Type().NumOut() != 1 {
+		return nil, false
+	}
+
+	v = conn.Call(nil)[0]
+	if isNil(v) {
+		return nil, false
+	}
+
+	// pgx.Conn.WaitForNotification() returns (*pgconn.Notification, error).
+	wait := v.MethodByName("WaitForNotification")
+	if !wait.IsValid() || wait.Type().NumIn() != 1 || wait.Type().NumOut() != 2 {
+		return nil, false
+	}
+
+	return func(ctx context.Context) (string, error) {
+		out := wait.Call([]reflect.Value{reflect.ValueOf(ctx)})
+
+		if err, _ := out[1].Interface().(error); err != nil {
+			return "", err
+		}
+
+		n := out[0]
+		if isNil(n) {
+			return "", nil
+		}
+
+		return reflect.Indirect(n).FieldByName("Payload").String(), nil
+	}, true
+}
+
+// isNil returns true if v is invalid, or is a nil value of a type that can be
+// nil.
+func isNil(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,168 @@
+package sqlprojection_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/dogmatiq/projectionkit/sqlprojection"
+	"github.com/dogmatiq/sqltest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("func WaitForResourceVersion()", func() {
+	for _, pair := range sqltest.CompatiblePairs(products...) {
+		pair := pair // capture loop variable
+
+		When(
+			fmt.Sprintf(
+				"using %s with the '%s' driver",
+				pair.Product.Name(),
+				pair.Driver.Name(),
+			),
+			func() {
+				var (
+					ctx      context.Context
+					cancel   context.CancelFunc
+					database *sqltest.Database
+					db       *sql.DB
+					repo     *ResourceRepository
+				)
+
+				BeforeEach(func() {
+					ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+
+					var err error
+					database, err = sqltest.NewDatabase(ctx, pair.Driver, pair.Product)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					db, err = database.Open()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = CreateSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					repo = NewResourceRepository(db, "<key>", WithNotifications(true))
+				})
+
+				AfterEach(func() {
+					err := DropSchema(ctx, db)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = database.Close()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					cancel()
+				})
+
+				It("returns immediately if the resource is already at the version", func() {
+					err := repo.StoreResourceVersion(ctx, []byte("<resource>"), []byte("<version 01>"))
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = WaitForResourceVersion(ctx, db, "<key>", []byte("<resource>"), []byte("<version 01>"))
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				It("returns immediately if the resource version sorts after the version", func() {
+					err := repo.StoreResourceVersion(ctx, []byte("<resource>"), []byte("<version 02>"))
+					Expect(err).ShouldNot(HaveOccurred())
+
+					err = WaitForResourceVersion(ctx, db, "<key>", []byte("<resource>"), []byte("<version 01>"))
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				It("blocks until the resource reaches the version", func() {
+					result := make(chan error, 1)
+					go func() {
+						result <- repo.WaitForResourceVersion(ctx, []byte("<resource>"), []byte("<version 02>"))
+					}()
+
+					ok, err := repo.UpdateResourceVersion(ctx, []byte("<resource>"), nil, []byte("<version 01>"))
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeTrue())
+
+					Consistently(result, 50*time.Millisecond).ShouldNot(Receive())
+
+					ok, err = repo.UpdateResourceVersion(ctx, []byte("<resource>"), []byte("<version 01>"), []byte("<version 02>"))
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ok).To(BeTrue())
+
+					Eventually(result, 3*time.Second).Should(Receive(BeNil()))
+				})
+
+				It("queries the version again while notifications about other resources are received", func() {
+					result := make(chan error, 1)
+					go func() {
+						result <- repo.WaitForResourceVersion(ctx, []byte("<resource>"), []byte("<version 01>"))
+					}()
+
+					stop := make(chan struct{})
+					defer close(stop)
+
+					go func() {
+						for i := 0; ; i++ {
+							select {
+							case <-stop:
+								return
+							case <-time.After(100 * time.Millisecond):
+								repo.StoreResourceVersion(ctx, []byte("<other>"), []byte(fmt.Sprintf("<version %02d>", i))) // nolint:errcheck
+							}
+						}
+					}()
+
+					// Store the version without sending a notification.
+					err := NewResourceRepository(db, "<key>").StoreResourceVersion(ctx, []byte("<resource>"), []byte("<version 01>"))
+					Expect(err).ShouldNot(HaveOccurred())
+
+					Eventually(result, 3*time.Second).Should(Receive(BeNil()))
+				})
+
+				It("returns an error if the context is canceled", func() {
+					waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+					defer cancel()
+
+					err := repo.WaitForResourceVersion(waitCtx, []byte("<resource>"), []byte("<version 01>"))
+					Expect(err).To(MatchError(context.DeadlineExceeded))
+				})
+			},
+		)
+	}
+
+	It("falls back to polling if the connection's Conn() method returns nil", func() {
+		db := sql.OpenDB(dsnConnector{driver: nilConnDriver{}})
+		defer db.Close()
+
+		err := WaitForResourceVersion(
+			context.Background(),
+			db,
+			"<key>",
+			[]byte("<resource>"),
+			[]byte("<version>"),
+			WithDriver(PostgresDriver),
+		)
+		Expect(err).To(MatchError("<error>"))
+	})
+})
+
+// nilConnDriver is a driver.Driver that returns connections with a Conn()
+// method that returns nil, and that fail to execute any statement.
+type nilConnDriver struct{}
+
+func (nilConnDriver) Open(string) (driver.Conn, error) { return nilConn{}, nil }
+
+type nilConn struct{}
+
+// notificationWaiter is the subset of the *pgx.Conn methods used to wait for
+// notifications.
+type notificationWaiter interface {
+	WaitForNotification(context.Context) (*struct{ Payload string }, error)
+}
+
+func (nilConn) Conn() notificationWaiter            { return nil }
+func (nilConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("<error>") }
+func (nilConn) Begin() (driver.Tx, error)           { return nil, errors.New("<error>") }
+func (nilConn) Close() error                        { return nil }